@@ -0,0 +1,71 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-air/gini/proof"
+)
+
+var checkProof = flag.String("check-proof", "", "check the DRAT proof (LRAT if it ends in .lrat) at this path against the input instead of solving")
+var core = flag.String("core", "", "with -check-proof, write the core of the input used by the proof to this path")
+
+// runCheckProof checks the proof at *checkProof against the cnf input,
+// returning the exit status.
+func runCheckProof() int {
+	if flag.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "can't use -check-proof with more than one input.\n")
+		return 1
+	}
+	var cnf io.Reader = os.Stdin
+	if flag.NArg() == 1 {
+		r, e := path2Reader(flag.Arg(0))
+		if e != nil {
+			log.Println(e)
+			return 1
+		}
+		cnf = r
+	}
+	prf, e := path2Reader(*checkProof)
+	if e != nil {
+		log.Println(e)
+		return 1
+	}
+	f := proof.Drat
+	p := strings.TrimSuffix(strings.TrimSuffix(*checkProof, ".gz"), ".bz2")
+	if strings.HasSuffix(p, ".lrat") {
+		f = proof.Lrat
+	}
+	start := time.Now()
+	c, e := proof.Check(cnf, prf, f)
+	log.Printf("checked %s proof in %s\n", f, time.Since(start))
+	if e != nil {
+		log.Println(e)
+		fmt.Printf("s NOT VERIFIED\n")
+		return 1
+	}
+	fmt.Printf("s VERIFIED\n")
+	log.Printf("core: %d clauses, %d lemmas\n", len(c.Core()), c.CoreLemmas())
+	if *core == "" {
+		return 0
+	}
+	w, e := os.Create(*core)
+	if e != nil {
+		log.Println(e)
+		return 1
+	}
+	defer w.Close()
+	if e := c.WriteCore(w); e != nil {
+		log.Println(e)
+		return 1
+	}
+	return 0
+}
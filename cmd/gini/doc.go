@@ -21,22 +21,94 @@
 //
 //    -assume value
 //      	add an assumption (default [])
+//    -bump-decay float
+//      	initial variable activity decay (default 0.95)
+//    -check-proof string
+//      	check the DRAT proof (LRAT if it ends in .lrat) at this path against the input instead of solving
+//    -chrono
+//      	backtrack chronologically when backjumping over many levels (default false)
+//    -chrono-levels uint
+//      	with -chrono, backjumps over more than this many levels backtrack chronologically (default 100)
+//    -core string
+//      	with -check-proof, write the core of the input used by the proof to this path
 //    -crisp string
 //      	address of crisp server to use
+//    -cube int
+//      	if non-zero, split the problem into cubes of at most this many literals by lookahead and solve them in parallel
+//    -cube-cands int
+//      	with -cube, maximum number of variables looked ahead at each split below the first, 0 for all (default 64)
+//    -decay-max float
+//      	initial variable activity decay at the end of each restart (default 0.935)
+//    -decay-max-decay float
+//      	factor by which -decay-max moves towards -decay-max-max at each restart (default 0.9999)
+//    -decay-max-max float
+//      	limit of -decay-max over restarts (default 0.9875)
+//    -decay-min float
+//      	variable activity decay at the start of each restart (default 0.67)
+//    -elim
+//      	eliminate variables and subsumed clauses before solving (default false)
+//    -elim-occs uint
+//      	with -elim, maximum number of clauses containing an eliminated variable (default 16)
 //    -failed
 //      	output failed assumptions
+//    -mode string
+//      	guessing mode, 'stable' (vsids), 'focused' (vmtf) or 'switch' (default "stable")
+//    -mode-conflicts uint
+//      	with -mode switch, number of conflicts before the first switch (default 2000)
 //    -model
 //      	output model (default false)
 //    -mon
 //      	if true, print statistics during solving (default false, implies -stats)
 //    -pprof string
 //      	address to serve http profile (eg :6060)
+//    -probe
+//      	learn the negations of failed literals before solving (default false)
+//    -prop-tick int
+//      	number of propagations between checks for timeouts (default 20000)
+//    -reduce-factor uint
+//      	number of learnt clauses in a unit of the luby sequence scheduling reductions (default 2048)
+//    -reduce-fraction float
+//      	fraction of learnt clauses considered for removal at each reduction (default 0.5)
+//    -rephase string
+//      	comma separated rephasing schedule of 'original', 'inverted', 'best', 'random' and 'walk'
+//    -rephase-interval uint
+//      	base number of conflicts between rephases (default 1000)
+//    -restart-after uint
+//      	minimum number of conflicts before restarting (default 1000)
+//    -restart-block float
+//      	with -restarts glucose, block restarts when the trail exceeds its average by this factor (default 1.4)
+//    -restart-factor uint
+//      	number of conflicts in a unit of the luby restart sequence (default 768)
+//    -restart-margin float
+//      	with -restarts glucose, restart when the recent average lbd exceeds the overall average by this factor (default 1.25)
+//    -restart-min-conflicts uint
+//      	with -restarts glucose, minimum number of conflicts between restarts (default 50)
+//    -restarts string
+//      	restart policy, 'luby', 'glucose' or 'none' (default "luby")
 //    -satcomp
 //      	if true, exit 10 sat, 20 unsat and output dimacs (default false)
+//    -seed int
+//      	if non-zero, seed for randomizing the initial variable order and phases
 //    -stats
 //      	if true, print some statistics after solving (default false)
+//    -subst
+//      	substitute equivalent literals before solving (default false)
+//    -target
+//      	guess using the values of the longest conflict free trail (default false)
+//    -tier-core uint
+//      	maximum glue of learnt clauses kept forever (default 2)
+//    -tier2 uint
+//      	maximum glue of learnt clauses kept while recently used (default 6)
+//    -tier2-window uint
+//      	number of conflicts for which unused tier2 clauses are kept (default 10000)
 //    -timeout duration
 //      	timeout (default 30s)
+//    -vivify-effort float
+//      	propagations spent vivifying learnt clauses relative to those spent searching, such as 0.1, or 0 for none
+//    -vivify-irredundant
+//      	vivify added clauses as well as learnt clauses (default false)
+//    -walk-flips uint
+//      	maximum number of flips for 'walk' rephasing (default 100000)
 //
 //
 package main
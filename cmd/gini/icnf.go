@@ -53,7 +53,7 @@ func newICnfGini(timeout time.Duration) *iCnfGini {
 	return &iCnfGini{
 		start: now,
 		end:   now.Add(timeout),
-		g:     gini.New()}
+		g:     gini.NewWithOptions(giniOptions())}
 }
 
 func (i *iCnfGini) Add(m z.Lit) {
@@ -101,7 +101,7 @@ type iCnfAx struct {
 func newICnfAx(timeout time.Duration, cap int) *iCnfAx {
 	a := &iCnfAx{
 		start: time.Now(),
-		g:     gini.New(),
+		g:     gini.NewWithOptions(giniOptions()),
 		cubes: make([][]z.Lit, 0, 1024)}
 	a.end = a.start.Add(timeout)
 	a.capacity = cap
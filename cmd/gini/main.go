@@ -14,11 +14,14 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-air/gini/ax"
 	proto "github.com/go-air/gini/crisp"
+	"github.com/go-air/gini/gen"
 	"github.com/go-air/gini/internal/xo"
 	"github.com/go-air/gini/z"
 )
@@ -31,6 +34,8 @@ var stats = flag.Bool("stats", false, "if true, print some statistics after solv
 var mon = flag.Duration("mon", 0*time.Second, "if non-zero, print statistics during solving (default 0, implies -stats)")
 var crisp = flag.String("crisp", "", "address of crisp server to use")
 var failed = flag.Bool("failed", false, "output failed assumptions")
+var cube = flag.Int("cube", 0, "if non-zero, split the problem into cubes of at most this many literals by lookahead and solve them in parallel")
+var cubeCands = flag.Int("cube-cands", 64, "with -cube, maximum number of variables looked ahead at each split below the first, 0 for all")
 
 type assumes []z.Lit
 
@@ -271,10 +276,18 @@ func runXoReader(r io.Reader) (int, error) {
 	}
 	dur := time.Since(start)
 	log.Printf("parsed dimacs in %s\n", dur)
+	opts, e := xoOptions()
+	if e != nil {
+		return 0, e
+	}
+	x.SetOptions(opts)
 
 	for _, a := range assumptions {
 		x.Assume(z.Lit(a))
 	}
+	if *cube != 0 {
+		return runXoCubes(x)
+	}
 	if *mon != 0 {
 		return runXoMonitored(x)
 	}
@@ -327,6 +340,76 @@ func runXoMonitored(x *xo.S) (int, error) {
 	return result, nil
 }
 
+// runXoCubes solves x by cube and conquer, splitting it into cubes with a
+// lookahead cuber and solving x under each cube in parallel with an ax.
+func runXoCubes(x *xo.S) (int, error) {
+	start := time.Now()
+	deadline := start.Add(*timeout)
+	cuber := gen.NewLookaheadCuber(x, *cube)
+	cuber.Cands = *cubeCands
+	cubes := cuber.Cubes()
+	log.Printf("generated %d cubes in %s\n", len(cubes), time.Since(start))
+
+	t := ax.NewT(x, runtime.NumCPU())
+	defer t.Stop()
+	rg := ax.NewRequestGen()
+	rg.SetFlag(0)
+	if *model {
+		rg.SetFlag(ax.ReqModel)
+	}
+	ms := make([]z.Lit, 0, len(assumptions)+*cube)
+	genReq := func(i int) *ax.Request {
+		ms = append(ms[:0], assumptions...)
+		ms = append(ms, cubes[i]...)
+		rg.Limit(time.Until(deadline))
+		return rg.New(ms...)
+	}
+	res, nUnsat := -1, 0
+	var resp *ax.Response
+	var req *ax.Request
+	i, ttl := 0, 0
+	if len(cubes) > 0 {
+		req = genReq(0)
+	}
+	for ttl < len(cubes) {
+		resp = t.Ex(req)
+		if resp == nil {
+			i++
+			req = nil
+			if i < len(cubes) {
+				req = genReq(i)
+			}
+			continue
+		}
+		ttl++
+		if resp.Res == 1 {
+			res = 1
+			break
+		}
+		if resp.Res == 0 {
+			res = 0
+		} else {
+			nUnsat++
+		}
+	}
+	if *stats {
+		log.Printf("solved %d of %d cubes, %d unsat, in %s\n", ttl, len(cubes), nUnsat, time.Since(start))
+	}
+	handleResultOutput(res)
+	if res == 1 && *model {
+		vals := make(crispValues, x.MaxVar()+1)
+		for _, m := range resp.Ms {
+			vals[m.Var()] = m.IsPos()
+		}
+		outputModel(x.MaxVar(), vals)
+	}
+	if res == -1 && *failed {
+		// the cubes only cover the problem under all the assumptions.
+		outputFailed(assumptions)
+	}
+	return res, nil
+}
+
 func runCrispReader(r io.Reader) (int, error) {
 	c, e := proto.Dial(*crisp)
 	if e != nil {
@@ -377,6 +460,10 @@ func main() {
 	log.SetPrefix("c [gini] ")
 	flag.Var(&assumptions, "assume", "add an assumption")
 	flag.Parse()
+	if _, e := xoOptions(); e != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", e)
+		os.Exit(1)
+	}
 	if flag.NArg() > 1 && *satcomp {
 		fmt.Fprintf(os.Stderr, "can't use -satcomp with more than one input.\n")
 		os.Exit(1)
@@ -388,6 +475,9 @@ func main() {
 			log.Println(http.ListenAndServe(*pprofAddr, nil))
 		}()
 	}
+	if *checkProof != "" {
+		os.Exit(runCheckProof())
+	}
 	if *satcomp {
 		runSatComp()
 		return
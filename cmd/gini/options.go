@@ -0,0 +1,109 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/internal/xo"
+)
+
+var restarts = flag.String("restarts", "luby", "restart policy, 'luby', 'glucose' or 'none'")
+
+var mode = flag.String("mode", "stable", "guessing mode, 'stable' (vsids), 'focused' (vmtf) or 'switch'")
+
+var rephase = flag.String("rephase", "", "comma separated rephasing schedule of 'original', 'inverted', 'best', 'random' and 'walk'")
+
+var opts = xo.DefaultOptions()
+
+func init() {
+	flag.UintVar(&opts.RestartFactor, "restart-factor", opts.RestartFactor, "number of conflicts in a unit of the luby restart sequence")
+	flag.UintVar(&opts.RestartAfter, "restart-after", opts.RestartAfter, "minimum number of conflicts before restarting")
+	flag.Float64Var(&opts.RestartMargin, "restart-margin", opts.RestartMargin, "with -restarts glucose, restart when the recent average lbd exceeds the overall average by this factor")
+	flag.Float64Var(&opts.RestartBlock, "restart-block", opts.RestartBlock, "with -restarts glucose, block restarts when the trail exceeds its average by this factor")
+	flag.UintVar(&opts.RestartMinConflicts, "restart-min-conflicts", opts.RestartMinConflicts, "with -restarts glucose, minimum number of conflicts between restarts")
+	flag.UintVar(&opts.ModeConflicts, "mode-conflicts", opts.ModeConflicts, "with -mode switch, number of conflicts before the first switch")
+	flag.BoolVar(&opts.TargetPhases, "target", opts.TargetPhases, "guess using the values of the longest conflict free trail")
+	flag.UintVar(&opts.RephaseInterval, "rephase-interval", opts.RephaseInterval, "base number of conflicts between rephases")
+	flag.UintVar(&opts.WalkFlips, "walk-flips", opts.WalkFlips, "maximum number of flips for 'walk' rephasing")
+	flag.BoolVar(&opts.Chrono, "chrono", opts.Chrono, "backtrack chronologically when backjumping over many levels")
+	flag.UintVar(&opts.ChronoLevels, "chrono-levels", opts.ChronoLevels, "with -chrono, backjumps over more than this many levels backtrack chronologically")
+	flag.Float64Var(&opts.VivifyEffort, "vivify-effort", opts.VivifyEffort, "propagations spent vivifying learnt clauses relative to those spent searching, such as 0.1, or 0 for none")
+	flag.BoolVar(&opts.VivifyIrredundant, "vivify-irredundant", opts.VivifyIrredundant, "vivify added clauses as well as learnt clauses")
+	flag.BoolVar(&opts.Eliminate, "elim", opts.Eliminate, "eliminate variables and subsumed clauses before solving")
+	flag.UintVar(&opts.ElimOccs, "elim-occs", opts.ElimOccs, "with -elim, maximum number of clauses containing an eliminated variable")
+	flag.BoolVar(&opts.Probe, "probe", opts.Probe, "learn the negations of failed literals before solving")
+	flag.BoolVar(&opts.Substitute, "subst", opts.Substitute, "substitute equivalent literals before solving")
+	flag.Float64Var(&opts.BumpDecay, "bump-decay", opts.BumpDecay, "initial variable activity decay")
+	flag.Float64Var(&opts.DecayMin, "decay-min", opts.DecayMin, "variable activity decay at the start of each restart")
+	flag.Float64Var(&opts.DecayMax, "decay-max", opts.DecayMax, "initial variable activity decay at the end of each restart")
+	flag.Float64Var(&opts.DecayMaxMax, "decay-max-max", opts.DecayMaxMax, "limit of -decay-max over restarts")
+	flag.Float64Var(&opts.DecayMaxDecay, "decay-max-decay", opts.DecayMaxDecay, "factor by which -decay-max moves towards -decay-max-max at each restart")
+	flag.UintVar(&opts.ReduceFactor, "reduce-factor", opts.ReduceFactor, "number of learnt clauses in a unit of the luby sequence scheduling reductions")
+	flag.Float64Var(&opts.ReduceFraction, "reduce-fraction", opts.ReduceFraction, "fraction of learnt clauses considered for removal at each reduction")
+	flag.UintVar(&opts.TierCore, "tier-core", opts.TierCore, "maximum glue of learnt clauses kept forever")
+	flag.UintVar(&opts.Tier2, "tier2", opts.Tier2, "maximum glue of learnt clauses kept while recently used")
+	flag.UintVar(&opts.Tier2Window, "tier2-window", opts.Tier2Window, "number of conflicts for which unused tier2 clauses are kept")
+	flag.Int64Var(&opts.PropTick, "prop-tick", opts.PropTick, "number of propagations between checks for timeouts")
+	flag.Int64Var(&opts.Seed, "seed", opts.Seed, "if non-zero, seed for randomizing the initial variable order and phases")
+}
+
+// xoOptions returns the solver options given by the command line flags.
+func xoOptions() (*xo.Options, error) {
+	switch *restarts {
+	case "luby":
+		opts.Restarts = xo.RestartLuby
+	case "none":
+		opts.Restarts = xo.RestartNone
+	case "glucose":
+		opts.Restarts = xo.RestartGlucose
+	default:
+		return nil, fmt.Errorf("unknown restart policy '%s'", *restarts)
+	}
+	switch *mode {
+	case "stable":
+		opts.Mode = xo.ModeStable
+	case "focused":
+		opts.Mode = xo.ModeFocused
+	case "switch":
+		opts.Mode = xo.ModeSwitch
+	default:
+		return nil, fmt.Errorf("unknown mode '%s'", *mode)
+	}
+	opts.Rephases = nil
+	if *rephase != "" {
+		for _, r := range strings.Split(*rephase, ",") {
+			switch r {
+			case "original":
+				opts.Rephases = append(opts.Rephases, xo.RephaseOriginal)
+			case "inverted":
+				opts.Rephases = append(opts.Rephases, xo.RephaseInverted)
+			case "best":
+				opts.Rephases = append(opts.Rephases, xo.RephaseBest)
+			case "random":
+				opts.Rephases = append(opts.Rephases, xo.RephaseRandom)
+			case "walk":
+				opts.Rephases = append(opts.Rephases, xo.RephaseWalk)
+			default:
+				return nil, fmt.Errorf("unknown rephase '%s'", r)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// giniOptions returns the options given by the command line flags for
+// use with gini.NewWithOptions.
+func giniOptions() gini.Options {
+	o, e := xoOptions()
+	if e != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", e)
+		os.Exit(1)
+	}
+	return *o
+}
@@ -6,9 +6,11 @@
 // Package gini contains both libraries and commands.  The libraries include
 //
 //  - A high quality, core single goroutine SAT solver (internal package xo).
-//  - Concurrent solving utilities (gini/ax, ...)
+//  - Concurrent solving utilities (gini/ax, NewPortfolio, ...)
 //  - CRISP-1.0 client and server (gini/crisp)
 //  - Generators (gini/gen)
+//  - MUS and MCS extraction and enumeration (gini/mus)
+//  - Weighted partial MaxSAT (gini/maxsat)
 //  - benchmarking library (gini/bench)
 //  - scoped assumptions
 //  - logic library
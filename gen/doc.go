@@ -5,5 +5,6 @@
 // kinds of formulas.
 //
 // Package gen also supplies a random solver, which returns
-// random results within a random period of time.
+// random results within a random period of time, and cubers, which
+// split problems into cubes of assumptions for parallel solving.
 package gen
@@ -0,0 +1,218 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package gen
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+// lookaheadCands is the default LookaheadCuber.Cands.
+const lookaheadCands = 64
+
+// LookaheadS is a solver on which a LookaheadCuber can look ahead.
+type LookaheadS interface {
+	inter.MaxVar
+	inter.Testable
+}
+
+// LookaheadCuber generates cubes for cube-and-conquer solving by looking
+// ahead with Test and Untest.
+//
+// The cubes form a binary tree.  At each node, the cuber tests both
+// literals of the free variables, and splits on the variable whose
+// literals propagate the most, measured by the product of the numbers of
+// literals they propagate.  Literals which fail, propagating to a
+// conflict, are negated in place, and nodes where both literals of a
+// variable fail are refuted.  The remaining nodes at depth Depth give the
+// cubes.
+//
+// The constraints of the solver imply the disjunction of the cubes, so
+// that the solver is unsat if it is unsat under each cube and there are
+// no cubes if the cuber finds the solver unsat.
+type LookaheadCuber struct {
+	// Depth is the maximum number of literals in a cube.
+	Depth int
+	// Cands is the maximum number of variables tested at each node below
+	// the root, chosen by their scores at the parent.  If Cands is 0,
+	// all free variables are tested, costing 2n tests at each node for
+	// n free variables, which is only practical for small problems.  The
+	// root always tests all free variables.
+	Cands int
+
+	s      LookaheadS
+	vals   []int8    // by variable, under the current tests
+	trail  []z.Var   // assigned variables, by test
+	marks  []int     // trail length at the start of each test
+	scores []float64 // by variable, at the last lookahead
+	cands  []z.Var
+	path   []z.Lit
+	props  []z.Lit
+	cubes  [][]z.Lit
+	x      bool // whether the solver is inconsistent after the last untest
+}
+
+// NewLookaheadCuber creates a cuber of s whose cubes have at most depth
+// literals, testing at most 64 candidate variables at each node below the
+// root.
+func NewLookaheadCuber(s LookaheadS, depth int) *LookaheadCuber {
+	return &LookaheadCuber{
+		Depth: depth,
+		Cands: lookaheadCands,
+		s:     s,
+		props: make([]z.Lit, 0, 128)}
+}
+
+// Cubes looks ahead under the current assumptions of the solver and
+// returns the cubes, leaving the solver as it was.
+func (c *LookaheadCuber) Cubes() [][]z.Lit {
+	M := c.s.MaxVar()
+	c.vals = make([]int8, M+1)
+	c.scores = make([]float64, M+1)
+	c.trail = c.trail[:0]
+	c.marks = c.marks[:0]
+	c.path = c.path[:0]
+	c.cubes = nil
+	c.x = false
+	if c.test(z.LitNull) != -1 {
+		c.node(0)
+	}
+	c.untest()
+	return c.cubes
+}
+
+// node looks ahead at depth d of the tree.
+func (c *LookaheadCuber) node(d int) {
+	if d >= c.Depth {
+		c.emit()
+		return
+	}
+	n := 0 // tests of failed literals at this node
+	defer func() {
+		for ; n > 0; n-- {
+			c.untest()
+		}
+	}()
+	best, bestScore := z.Var(0), -1.0
+	for _, v := range c.candidates(d) {
+		if c.vals[v] != 0 {
+			continue
+		}
+		np, rp := c.look(v.Pos())
+		if c.x {
+			return
+		}
+		nn, rn := c.look(v.Neg())
+		if c.x {
+			return
+		}
+		switch {
+		case rp == 1 || rn == 1:
+			// a model.
+			c.emit()
+			return
+		case rp == -1 && rn == -1:
+			return
+		case rp == -1 || rn == -1:
+			m := v.Pos()
+			if rp == -1 {
+				m = m.Not()
+			}
+			n++
+			if c.test(m) == -1 {
+				return
+			}
+			continue
+		}
+		sc := float64(np) * float64(nn)
+		c.scores[v] = sc
+		if sc > bestScore {
+			best, bestScore = v, sc
+		}
+	}
+	if best == 0 {
+		c.emit()
+		return
+	}
+	for _, m := range [...]z.Lit{best.Pos(), best.Neg()} {
+		if c.test(m) != -1 {
+			c.path = append(c.path, m)
+			c.node(d + 1)
+			c.path = c.path[:len(c.path)-1]
+		}
+		c.untest()
+		if c.x {
+			// clauses learnt below refute the node.
+			return
+		}
+	}
+}
+
+// candidates returns the variables to test at depth d.
+func (c *LookaheadCuber) candidates(d int) []z.Var {
+	c.cands = c.cands[:0]
+	for v := z.Var(1); v < z.Var(len(c.vals)); v++ {
+		if c.vals[v] == 0 {
+			c.cands = append(c.cands, v)
+		}
+	}
+	if d == 0 || c.Cands <= 0 || len(c.cands) <= c.Cands {
+		return c.cands
+	}
+	scores := c.scores
+	sort.SliceStable(c.cands, func(i, j int) bool {
+		return scores[c.cands[i]] > scores[c.cands[j]]
+	})
+	return c.cands[:c.Cands]
+}
+
+// look tests m, returning the number of literals it propagates and the
+// result of the test.
+func (c *LookaheadCuber) look(m z.Lit) (int, int) {
+	r := c.test(m)
+	n := len(c.trail) - c.marks[len(c.marks)-1]
+	c.untest()
+	return n, r
+}
+
+// test assumes m, unless m is z.LitNull, and tests.
+func (c *LookaheadCuber) test(m z.Lit) int {
+	if m != z.LitNull {
+		c.s.Assume(m)
+	}
+	r, ms := c.s.Test(c.props[:0])
+	c.marks = append(c.marks, len(c.trail))
+	for _, n := range ms {
+		v := n.Var()
+		if v >= z.Var(len(c.vals)) || c.vals[v] != 0 {
+			continue
+		}
+		c.vals[v] = 1
+		if !n.IsPos() {
+			c.vals[v] = -1
+		}
+		c.trail = append(c.trail, v)
+	}
+	c.props = ms
+	return r
+}
+
+// untest undoes the last test, recording whether the solver remains
+// inconsistent, in which case the node is refuted and no further tests may
+// be made until the solver is consistent again.
+func (c *LookaheadCuber) untest() {
+	c.x = c.s.Untest() == -1
+	i := c.marks[len(c.marks)-1]
+	c.marks = c.marks[:len(c.marks)-1]
+	for _, v := range c.trail[i:] {
+		c.vals[v] = 0
+	}
+	c.trail = c.trail[:i]
+}
+
+func (c *LookaheadCuber) emit() {
+	c.cubes = append(c.cubes, append([]z.Lit(nil), c.path...))
+}
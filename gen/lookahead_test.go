@@ -0,0 +1,66 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package gen
+
+import (
+	"testing"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/z"
+)
+
+// checkCover checks that the clauses of g imply the disjunction of cubes.
+func checkCover(t *testing.T, g *gini.Gini, cubes [][]z.Lit) {
+	h := g.Copy()
+	for _, cube := range cubes {
+		for _, m := range cube {
+			h.Add(m.Not())
+		}
+		h.Add(z.LitNull)
+	}
+	if h.Solve() != -1 {
+		t.Errorf("cubes do not cover")
+	}
+}
+
+func TestLookaheadCuber(t *testing.T) {
+	g := gini.New()
+	Php(g, 7, 6)
+	cubes := NewLookaheadCuber(g, 4).Cubes()
+	if len(cubes) == 0 || len(cubes) > 16 {
+		t.Fatalf("%d cubes", len(cubes))
+	}
+	for _, cube := range cubes {
+		if len(cube) > 4 {
+			t.Errorf("cube %v too long", cube)
+		}
+		g.Assume(cube...)
+		if g.Solve() != -1 {
+			t.Errorf("php sat under %v", cube)
+		}
+	}
+	checkCover(t, g, cubes)
+
+	for i := 0; i < 10; i++ {
+		g = gini.New()
+		Rand3Cnf(g, 80, 340)
+		c := NewLookaheadCuber(g, 5)
+		c.Cands = 10
+		if i%2 == 1 {
+			// all free variables.
+			c.Cands = 0
+		}
+		cubes = c.Cubes()
+		if len(cubes) == 0 {
+			if g.Solve() != -1 {
+				t.Errorf("no cubes for sat problem")
+			}
+			continue
+		}
+		checkCover(t, g, cubes)
+		// g is left as it was.
+		g.Add(z.Var(1).Pos())
+		g.Add(z.LitNull)
+	}
+}
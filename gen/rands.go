@@ -4,6 +4,7 @@
 package gen
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -185,6 +186,16 @@ func (c *ctl) Try(d time.Duration) int {
 	}
 }
 
+func (c *ctl) SolveContext(ctx context.Context) int {
+	select {
+	case r := <-c.resChn:
+		c.s.unlock()
+		return r
+	case <-ctx.Done():
+		return c.Stop()
+	}
+}
+
 func (c *ctl) Pause() (int, bool) {
 	select {
 	case r := <-c.resChn:
@@ -4,6 +4,7 @@
 package gini
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -32,6 +33,13 @@ func New() *Gini {
 	return g
 }
 
+// NewWithOptions creates a new gini solver with tuning options opts.
+func NewWithOptions(opts Options) *Gini {
+	g := New()
+	g.xo.SetOptions(&opts)
+	return g
+}
+
 // NewDimacs create a new gini solver from
 // dimacs formatted input.
 func NewDimacs(r io.Reader) (*Gini, error) {
@@ -133,6 +141,28 @@ func (g *Gini) Try(dur time.Duration) int {
 	return g.xo.Try(dur)
 }
 
+// SolveContext solves until ctx is canceled or its deadline passes.
+// SolveContext returns
+//  1  if sat
+//  -1 if unsat
+//  0  if ctx is done first
+func (g *Gini) SolveContext(ctx context.Context) int {
+	return g.xo.SolveContext(ctx)
+}
+
+// TryBudget solves with a budget of conflicts and propagations, counted
+// as in the Conflicts and Props stats.  A budget which is 0 or less is
+// not limited.  TryBudget returns
+//  1  if sat
+//  -1 if unsat
+//  0  if a budget is exhausted
+//
+// Unlike Try, the result of TryBudget does not depend on timing, so that
+// it is reproducible.
+func (g *Gini) TryBudget(conflicts, props int64) int {
+	return g.xo.TryBudget(conflicts, props)
+}
+
 // GoSolve provides a connection to a single background
 // solving goroutine, a goroutine which calls Solve()
 func (g *Gini) GoSolve() inter.Solve {
@@ -146,7 +176,7 @@ func (g *Gini) GoSolve() inter.Solve {
 // in a model of of the underlying problem, where that
 // model is determined by the previous call to Solve().
 func (g *Gini) Value(m z.Lit) bool {
-	return g.xo.Vars.Vals[m] == 1
+	return g.xo.Value(m)
 }
 
 // Why returns the slice of failed assumptions, a minimized
@@ -272,6 +302,195 @@ func (g *Gini) Deactivate(m z.Lit) {
 	g.xo.Deactivate(m)
 }
 
+// AddGroup causes subsequently added clauses to belong to the group
+// identified by id, until EndGroup or the next call to AddGroup.  Calling
+// AddGroup with the id of an existing group adds more clauses to it.
+//
+// Groups give clause level unsat cores: after Solve returns unsat,
+// GroupWhy gives the groups whose clauses together were unsat.  Groups are
+// implemented with activation literals, which are assumed by Solve and
+// Test and are hidden from Why.  Only clauses added with Add are grouped,
+// not constraints added with AddXor, AddAtMost, AddAtLeast or AddPb.
+// The activation variables are allocated above MaxVar when g next solves
+// or tests, so variables introduced afterwards should be allocated with
+// Lit.
+//
+// Example:
+//
+//  g.AddGroup(1)
+//  g.Add(a)
+//  g.Add(0)
+//  g.AddGroup(2)
+//  g.Add(a.Not())
+//  g.Add(0)
+//  g.EndGroup()
+//  if g.Solve() == -1 {
+//    core := g.GroupWhy(nil) // [1 2]
+//  }
+//
+// AddGroup is an unsupported operation under a test scope
+// and will panic if called under a test scope.
+func (g *Gini) AddGroup(id int) {
+	g.xo.AddGroup(id)
+}
+
+// EndGroup ends the group opened by the last call to AddGroup, so that
+// subsequently added clauses belong to no group.
+func (g *Gini) EndGroup() {
+	g.xo.EndGroup()
+}
+
+// RemoveGroup removes the clauses of the group identified by id, including
+// learned clauses which depend on them.
+//
+// RemoveGroup is an unsupported operation under a test scope
+// and will panic if called under a test scope.
+func (g *Gini) RemoveGroup(id int) {
+	g.xo.RemoveGroup(id)
+}
+
+// GroupWhy appends to dst the ids, in increasing order, of the groups in
+// the core of the last call to Solve, the groups whose clauses were used
+// to show it unsat.  Only group ids are returned: the core may also use
+// clauses belonging to no group, and the failed assumptions are given by
+// Why.
+//
+// If the last call was not unsat, or was unsat regardless of groups,
+// GroupWhy returns dst.
+func (g *Gini) GroupWhy(dst []int) []int {
+	return g.xo.GroupWhy(dst)
+}
+
+// Freeze prevents the variable v from being eliminated when
+// Options.Eliminate is set, until a corresponding call to Melt.  Calls
+// to Freeze and Melt nest.  If v is already eliminated, Freeze restores
+// it.
+//
+// Eliminated variables which are used in subsequently added clauses or
+// assumptions are restored automatically, and all of them are restored
+// when a test scope is opened.  Restoring is costly, so incremental users
+// should freeze such variables before the first call to Solve.
+func (g *Gini) Freeze(v z.Var) {
+	g.xo.Freeze(v)
+}
+
+// Melt undoes a call to Freeze.
+func (g *Gini) Melt(v z.Var) {
+	g.xo.Melt(v)
+}
+
+// AddXor adds the constraint that an odd number of the literals in ms
+// are true if rhs is true, and an even number otherwise.
+//
+// Xor constraints are propagated by Gauss-Jordan elimination and their
+// variables are frozen, see Freeze.  Like clauses, they may be added
+// between calls to Solve but not under a test scope.  They are not
+// written by Write.  If a proof is being written, see SetProof, xor
+// constraints are added as clauses instead, so that the proof accounts
+// for them.  These clauses use fresh variables obtained as with Lit, so
+// callers should not use variables above MaxVar which were not obtained
+// from Lit.
+func (g *Gini) AddXor(ms []z.Lit, rhs bool) {
+	g.xo.AddXor(ms, rhs)
+}
+
+// AddAtMost adds the constraint that at most k of the literals in ms are
+// true, counting repeated literals once per occurrence.
+//
+// Cardinality constraints are propagated natively, with reasons for
+// conflict analysis generated only for the literals they imply, so no
+// sorting network needs to be built.  As with AddXor, their variables are
+// frozen, they may not be added under a test scope, they are not written
+// by Write and, if a proof is being written, they are added as clauses
+// instead.
+func (g *Gini) AddAtMost(ms []z.Lit, k int) {
+	g.xo.AddAtMost(ms, k)
+}
+
+// AddAtLeast adds the constraint that at least k of the literals in ms are
+// true, as AddAtMost does for the negations of ms.
+func (g *Gini) AddAtLeast(ms []z.Lit, k int) {
+	g.xo.AddAtLeast(ms, k)
+}
+
+// AddPb adds the linear pseudo-Boolean constraint that the sum of the
+// weights ws[i] of the true literals ms[i] is at least k, implementing
+// inter.PbAdder.  Weights may be negative.
+//
+// Pseudo-Boolean constraints are propagated natively like cardinality
+// constraints, see AddAtMost, with reasons containing the heaviest true
+// literals which suffice.
+func (g *Gini) AddPb(ms []z.Lit, ws []int, k int) {
+	g.xo.AddPb(ms, ws, k)
+}
+
+// SetExport causes g to call f with each clause g learns which has at most
+// maxLen literals and an lbd of at most maxLbd.  The lbd of a learnt clause
+// is the number of distinct decision levels of its literals other than
+// the first when it is learnt, so that units have lbd 0.  If f is nil,
+// then export is disabled.
+//
+// f is called in the goroutine solving g, and must neither retain nor
+// modify ms.  Exported clauses are implied by the constraints added to g,
+// and may be given to Import of another solver with the same constraints
+// in order to share learnt clauses.
+func (g *Gini) SetExport(maxLen, maxLbd int, f func(ms []z.Lit, lbd int)) {
+	g.xo.SetExport(maxLen, maxLbd, f)
+}
+
+// Import queues the clause ms, which should be implied by the constraints
+// added to g, to be learnt by g.  Import may be called from any goroutine,
+// including while g is solving.  Queued clauses are added at the start of
+// a call to Solve and at restarts, outside of test scopes.  At restarts
+// under assumptions, g backtracks to add them and then makes the
+// assumptions again.
+//
+// Clauses with variables g does not know, or which have been eliminated
+// by g, are dropped, as are all clauses if g writes a proof, since they
+// are not derived in the proof.
+func (g *Gini) Import(ms []z.Lit) {
+	g.xo.Import(ms)
+}
+
+// ProofFormat identifies a format for proofs of unsatisfiability.
+type ProofFormat int
+
+const (
+	// DratText is the textual DRAT proof format.
+	DratText ProofFormat = ProofFormat(xo.ProofDrat)
+	// DratBinary is the binary DRAT proof format.
+	DratBinary ProofFormat = ProofFormat(xo.ProofDratBinary)
+	// LratText is the textual LRAT proof format, which gives for each
+	// learned clause the ids of the clauses from which it follows by unit
+	// propagation.
+	LratText ProofFormat = ProofFormat(xo.ProofLrat)
+	// LratBinary is the binary LRAT proof format.
+	LratBinary ProofFormat = ProofFormat(xo.ProofLratBinary)
+)
+
+// SetProof causes g to write a proof in format f to w, so that unsat
+// results may be checked by an independent proof checker.  The proof
+// lists every clause learned by g and every clause g removes, and ends
+// with the empty clause once g finds the problem unsat without
+// assumptions.
+//
+// The proof refers to the clauses added to g after the call to SetProof,
+// so SetProof should be called before any clauses are added.  Output is
+// buffered and flushed at the end of each call to Solve.  If w is nil,
+// proof output is disabled.
+//
+// LRAT proofs identify the clauses added to g by their position, starting
+// from 1, and so all clauses must be added before the first call to Solve.
+func (g *Gini) SetProof(w io.Writer, f ProofFormat) {
+	g.xo.SetProof(w, xo.ProofFormat(f))
+}
+
+// ProofError returns the first error encountered writing the proof set
+// by SetProof, if any.
+func (g *Gini) ProofError() error {
+	return g.xo.ProofError()
+}
+
 // Write writes the underlying CNF in dimacs format to dst,
 // returning any i/o error which occured in the process.
 func (g *Gini) Write(dst io.Writer) error {
@@ -4,10 +4,13 @@
 package gini
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/internal/xo"
 	"github.com/go-air/gini/z"
 )
 
@@ -60,3 +63,338 @@ func TestGiniAsync(t *testing.T) {
 		t.Logf("cancelled late. %s > %s", sDur, timeout)
 	}
 }
+
+func TestGiniSolveContext(t *testing.T) {
+	g := New()
+	gen.Php(g, 15, 14)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if r := g.SolveContext(ctx); r != 0 {
+		t.Errorf("solved hard php before cancel: %d", r)
+	}
+	// the solver remains usable after cancelation.
+	g.Assume(z.Var(1).Pos(), z.Var(1).Neg())
+	if r := g.Solve(); r != -1 {
+		t.Errorf("contradictory assumptions not unsat: %d", r)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	g = New()
+	gen.Php(g, 5, 4)
+	if r := g.SolveContext(ctx); r != -1 {
+		t.Errorf("easy php not unsat: %d", r)
+	}
+}
+
+func TestGiniTryBudget(t *testing.T) {
+	var _ inter.BudgetSolvable = New()
+	g := New()
+	gen.Php(g, 15, 14)
+	if r := g.TryBudget(1000, 0); r != 0 {
+		t.Errorf("solved hard php in 1000 conflicts: %d", r)
+	}
+	if r := g.TryBudget(0, 100000); r != 0 {
+		t.Errorf("solved hard php in 100000 props: %d", r)
+	}
+	g = New()
+	gen.Php(g, 5, 4)
+	if r := g.TryBudget(100000, 0); r != -1 {
+		t.Errorf("easy php not unsat within budget: %d", r)
+	}
+}
+
+func TestGiniOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{},
+		{Seed: 17},
+		{Restarts: RestartNone},
+		{Restarts: RestartGlucose},
+		{Mode: ModeFocused},
+		{Mode: ModeSwitch, ModeConflicts: 100},
+		{Mode: ModeSwitch, Restarts: RestartGlucose, Seed: 3},
+		{TargetPhases: true},
+		{TargetPhases: true, RephaseInterval: 50, Rephases: []Rephase{
+			RephaseOriginal, RephaseBest, RephaseWalk, RephaseInverted, RephaseRandom}},
+		{Mode: ModeSwitch, Rephases: []Rephase{RephaseWalk, RephaseBest}, RephaseInterval: 20, WalkFlips: 100, Seed: 5},
+		{Restarts: RestartGlucose, RestartMargin: 1.1, RestartBlock: 1.2, RestartMinConflicts: 20},
+		{RestartFactor: 64, ReduceFactor: 256, ReduceFraction: 0.75},
+		{Chrono: true},
+		{Chrono: true, ChronoLevels: 1, Mode: ModeSwitch},
+		{VivifyEffort: -1},
+		{VivifyEffort: 1, VivifyIrredundant: true},
+		{Eliminate: true},
+		{Eliminate: true, ElimOccs: 4, Mode: ModeSwitch},
+		{Probe: true, Substitute: true},
+		{Substitute: true, Eliminate: true},
+		{TierCore: 3, Tier2: 4, Tier2Window: 100, ReduceFactor: 64},
+		{DecayMin: 0.8, DecayMax: 0.95, PropTick: 1000}} {
+		g := NewWithOptions(opts)
+		gen.Php(g, 7, 6)
+		if g.Solve() != -1 {
+			t.Errorf("%+v: php not unsat", opts)
+		}
+		g = NewWithOptions(opts)
+		gen.Php(g, 6, 6)
+		if g.Solve() != 1 {
+			t.Errorf("%+v: php not sat", opts)
+		}
+	}
+}
+
+func TestGiniOptionsEffect(t *testing.T) {
+	// a failed literal a and equivalent literals b and c besides php.
+	a, b, c := z.Var(201).Pos(), z.Var(202).Pos(), z.Var(203).Pos()
+	extra := [][]z.Lit{{a.Not(), b}, {a.Not(), c}, {b.Not(), c.Not()},
+		{b.Not(), c}, {b, c.Not()}, {b, z.Var(1).Pos()}}
+	restarts := Options{RestartAfter: 1, RestartFactor: 8}
+	for _, tc := range []struct {
+		opts Options
+		ok   func(st *xo.Stats) bool
+	}{
+		{Options{}, func(st *xo.Stats) bool {
+			return st.ModeSwitches == 0 && st.Rephases == 0 &&
+				st.ChronoBacks == 0 && st.VivifyChecked == 0 && st.Eliminated == 0 &&
+				st.Substituted == 0 && st.Probed == 0
+		}},
+		{restarts, func(st *xo.Stats) bool {
+			return st.Restarts > 0 && st.VivifyChecked == 0
+		}},
+		{Options{Restarts: RestartNone, RestartAfter: 1, RestartFactor: 8}, func(st *xo.Stats) bool {
+			return st.Restarts == 0
+		}},
+		{Options{Mode: ModeSwitch, ModeConflicts: 100}, func(st *xo.Stats) bool {
+			return st.ModeSwitches > 0
+		}},
+		{Options{RestartAfter: 1, RestartFactor: 8, Rephases: []Rephase{RephaseWalk}, RephaseInterval: 10}, func(st *xo.Stats) bool {
+			return st.Rephases > 0 && st.WalkFlips > 0
+		}},
+		{Options{Chrono: true, ChronoLevels: 1}, func(st *xo.Stats) bool {
+			return st.ChronoBacks > 0
+		}},
+		{Options{RestartAfter: 1, RestartFactor: 8, VivifyEffort: 1}, func(st *xo.Stats) bool {
+			return st.VivifyChecked > 0
+		}},
+		{Options{Eliminate: true}, func(st *xo.Stats) bool {
+			return st.Eliminated > 0
+		}},
+		{Options{Substitute: true}, func(st *xo.Stats) bool {
+			return st.Substituted > 0 && st.Probed == 0
+		}},
+		{Options{Probe: true}, func(st *xo.Stats) bool {
+			return st.FailedLits > 0 && st.Substituted == 0
+		}}} {
+		g := NewWithOptions(tc.opts)
+		gen.Php(g, 9, 8)
+		for _, ms := range extra {
+			for _, m := range ms {
+				g.Add(m)
+			}
+			g.Add(0)
+		}
+		if g.Solve() != -1 {
+			t.Errorf("%+v: php not unsat", tc.opts)
+		}
+		st := xo.NewStats()
+		g.xo.ReadStats(st)
+		if !tc.ok(st) {
+			t.Errorf("%+v: no effect: %s", tc.opts, st)
+		}
+	}
+}
+
+func TestGiniXor(t *testing.T) {
+	// an odd cycle of xors each stating that its ends differ.
+	n := 101
+	g := New()
+	for i := 1; i <= n; i++ {
+		g.AddXor([]z.Lit{z.Var(i).Pos(), z.Var(i%n + 1).Pos()}, true)
+	}
+	if g.Solve() != -1 {
+		t.Errorf("odd xor cycle not unsat")
+	}
+	g = New()
+	for i := 1; i < n; i++ {
+		g.AddXor([]z.Lit{z.Var(i).Pos(), z.Var(i + 1).Neg()}, false)
+	}
+	g.Assume(z.Var(1).Pos())
+	if g.Solve() != 1 {
+		t.Fatalf("xor path not sat")
+	}
+	for i := 1; i <= n; i++ {
+		if g.Value(z.Var(i).Pos()) != (i%2 == 1) {
+			t.Errorf("wrong value of %d", i)
+		}
+	}
+}
+
+func TestGiniCard(t *testing.T) {
+	// at least 3 of 5 and at most 1 of each of the pairs {1,2} and
+	// {3,4} forces 5, 1 xor 2 and 3 xor 4.
+	g := New()
+	ms := make([]z.Lit, 5)
+	for i := range ms {
+		ms[i] = z.Var(i + 1).Pos()
+	}
+	g.AddAtLeast(ms, 3)
+	g.AddAtMost(ms[:2], 1)
+	g.AddAtMost(ms[2:4], 1)
+	if g.Solve() != 1 {
+		t.Fatalf("card not sat")
+	}
+	if !g.Value(ms[4]) || g.Value(ms[0]) == g.Value(ms[1]) || g.Value(ms[2]) == g.Value(ms[3]) {
+		t.Errorf("wrong model")
+	}
+	g.Assume(ms[4].Not())
+	if g.Solve() != -1 {
+		t.Errorf("card sat without 5")
+	}
+	g.AddAtMost(ms, 2)
+	if g.Solve() != -1 {
+		t.Errorf("card sat with at most 2")
+	}
+}
+
+func TestGiniPb(t *testing.T) {
+	var _ inter.PbAdder = New()
+	// 3a + 2b + 2c - d >= 4 with at most one of b and c forces a.
+	g := New()
+	a, b, c, d := z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos(), z.Var(4).Pos()
+	g.AddPb([]z.Lit{a, b, c, d}, []int{3, 2, 2, -1}, 4)
+	g.AddAtMost([]z.Lit{b, c}, 1)
+	if g.Solve() != 1 {
+		t.Fatalf("pb not sat")
+	}
+	if !g.Value(a) {
+		t.Errorf("a false")
+	}
+	g.Assume(d)
+	if g.Solve() != 1 {
+		t.Fatalf("pb not sat with d")
+	}
+	if !g.Value(a) || g.Value(b) == g.Value(c) {
+		t.Errorf("wrong model with d")
+	}
+	g.Assume(d, b.Not(), c.Not())
+	if g.Solve() != -1 {
+		t.Errorf("pb sat with d and neither b nor c")
+	}
+}
+
+func TestGiniGroups(t *testing.T) {
+	var _ inter.Groupable = New()
+	// pigeon i in group i, at most one pigeon per hole in no group.
+	g := New()
+	P, H := 5, 4
+	v := func(p, h int) z.Lit { return z.Var(p*H + h + 1).Pos() }
+	for p := 0; p < P; p++ {
+		g.AddGroup(p)
+		for h := 0; h < H; h++ {
+			g.Add(v(p, h))
+		}
+		g.Add(0)
+	}
+	g.EndGroup()
+	for h := 0; h < H; h++ {
+		for p := 0; p < P; p++ {
+			for q := p + 1; q < P; q++ {
+				g.Add(v(p, h).Not())
+				g.Add(v(q, h).Not())
+				g.Add(0)
+			}
+		}
+	}
+	if g.Solve() != -1 {
+		t.Fatalf("php sat")
+	}
+	if ids := g.GroupWhy(nil); len(ids) != P {
+		t.Errorf("core %v", ids)
+	}
+	if ms := g.Why(nil); len(ms) != 0 {
+		t.Errorf("why %v", ms)
+	}
+	g.RemoveGroup(3)
+	if g.Solve() != 1 {
+		t.Fatalf("php sat without a pigeon")
+	}
+	for p := 0; p < P; p++ {
+		n := 0
+		for h := 0; h < H; h++ {
+			if g.Value(v(p, h)) {
+				n++
+			}
+		}
+		if p != 3 && n == 0 {
+			t.Errorf("pigeon %d in no hole", p)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var _ inter.Solvable = &Walk{}
+	var _ inter.Model = &Walk{}
+	// an instance which is known to be sat.
+	gen.Seed(1)
+	g := NewWithOptions(Options{WalkFlips: 1000000})
+	gen.Rand3Cnf(g, 300, 1200)
+	w := NewWalk(g, 1)
+	if w.Solve() != 1 {
+		t.Fatalf("walk left %d false clauses", w.Unsat())
+	}
+	for i := 1; i <= 300; i++ {
+		m := z.Var(i).Pos()
+		if !w.Value(m) {
+			m = m.Not()
+		}
+		g.Assume(m)
+	}
+	if g.Solve() != 1 {
+		t.Errorf("walk model is not a model")
+	}
+	g = New()
+	gen.Php(g, 5, 4)
+	if r := NewWalk(g, 1).Try(10 * time.Millisecond); r != 0 {
+		t.Errorf("walk php result %d", r)
+	}
+}
+
+func TestGiniShare(t *testing.T) {
+	g := New()
+	gen.Php(g, 9, 8)
+	h := g.Copy()
+	n := 0
+	g.SetExport(4, 2, func(ms []z.Lit, lbd int) {
+		n++
+		h.Import(ms)
+	})
+	if g.Solve() != -1 {
+		t.Fatalf("php sat")
+	}
+	if n == 0 {
+		t.Fatalf("nothing exported")
+	}
+	if h.Solve() != -1 {
+		t.Errorf("php sat with imports")
+	}
+	h.Import([]z.Lit{z.Var(1000).Pos()})
+	if h.Solve() != -1 {
+		t.Errorf("php sat with unknown import")
+	}
+}
+
+func TestPortfolio(t *testing.T) {
+	p := NewPortfolio(4)
+	defer p.Stop()
+	gen.Php(p, 7, 6)
+	if p.Solve() != -1 {
+		t.Errorf("php not unsat")
+	}
+	p = NewPortfolio(3)
+	defer p.Stop()
+	gen.Php(p, 6, 6)
+	if p.Solve() != 1 {
+		t.Errorf("php not sat")
+	}
+}
@@ -24,6 +24,14 @@ type Solvable interface {
 	Try(dur time.Duration) int
 }
 
+// BudgetSolvable is something which can solve with a deterministic budget
+// of conflicts and propagations rather than a duration.  TryBudget
+// returns like Try, with 0 if a budget is exhausted.  A budget which is 0
+// or less is not limited.
+type BudgetSolvable interface {
+	TryBudget(conflicts, props int64) int
+}
+
 // Interface GoSolvable encapsulates a handle
 // on a Solve running in its own goroutine.
 type GoSolvable interface {
@@ -44,6 +52,14 @@ type Adder interface {
 	Add(m z.Lit)
 }
 
+// PbAdder encapsulates something to which linear pseudo-Boolean
+// constraints can be added.
+type PbAdder interface {
+	// AddPb adds the constraint that the sum of the weights ws[i] of
+	// the true literals ms[i] is at least k.  Weights may be negative.
+	AddPb(ms []z.Lit, ws []int, k int)
+}
+
 // Interface MaxVar is something which records the
 // maximum variable from a stream of inputs (such
 // as Adds/Assumes) and can return the maximum of
@@ -193,3 +209,21 @@ type Activatable interface {
 	// like `Add()`, Deactivate should only be called at decision level 0.
 	Deactivate(m z.Lit)
 }
+
+// Groupable provides unsat cores in terms of groups of clauses, tagged by
+// user supplied ids.
+type Groupable interface {
+	// AddGroup causes subsequently added clauses to belong to the group
+	// id, until EndGroup or the next AddGroup.
+	AddGroup(id int)
+
+	// EndGroup ends the open group, if any.
+	EndGroup()
+
+	// RemoveGroup removes the clauses of the group id.
+	RemoveGroup(id int)
+
+	// GroupWhy appends to dst the ids of groups whose clauses together
+	// caused the last call to be unsat.
+	GroupWhy(dst []int) []int
+}
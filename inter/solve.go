@@ -3,7 +3,10 @@
 
 package inter
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Interface Solve represents a connection to a call to (S).Solve().
 //
@@ -31,6 +34,10 @@ type Solve interface {
 	// Try lets Solve() run for at most d time and then returns the result.
 	Try(d time.Duration) int
 
+	// SolveContext lets Solve() run until ctx is done and then returns
+	// the result, defaulting to 0 if the answer is unknown.
+	SolveContext(ctx context.Context) int
+
 	// Test checks whether or not a result is ready, and if so returns it
 	// together with true.  If not, it returns (0, false).
 	Test() (int, bool)
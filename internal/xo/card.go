@@ -0,0 +1,437 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/z"
+)
+
+// card is the constraint that the sum of the weights of its true literals
+// is at most k.  Cardinality constraints have unit weights.
+type card struct {
+	ms      []z.Lit
+	ws      []int // weights of ms, in decreasing order
+	k       int
+	n       int // sum of the weights of the counted true literals
+	pending bool
+}
+
+// cardOcc is an occurrence of a literal in constraint i with weight w.
+type cardOcc struct {
+	i int
+	w int
+}
+
+// cards holds cardinality and pseudo-Boolean constraints and propagates
+// them next to unit propagation by summing the weights of the true
+// literals of each constraint.
+//
+// Each constraint watches all of its literals.  A literal which becomes
+// true adds its weight to the sums of the constraints containing it when
+// it is processed from the trail, and subtracts it when it is unassigned
+// by backtracking.  When the slack of a constraint, k minus its sum, is
+// less than the weight of an unassigned literal, the literal is implied
+// false, and when the slack is negative, the constraint is a conflict.
+//
+// Reasons are generated lazily, only for the literals which are actually
+// implied, by adding learnt clauses consisting of the implied literal and
+// the negations of the heaviest true literals whose weights suffice, so
+// that conflict analysis works as for clauses.
+type cards struct {
+	cs      []card
+	occs    [][]cardOcc // by literal
+	counted []bool      // by variable, whether counted as true
+	pending []int
+	head    int // trail position of the next assignment to process
+	ms      []z.Lit
+	sums    []int
+
+	stCards     int64
+	stProps     int64
+	stConflicts int64
+}
+
+func newCards(capHint int) *cards {
+	return &cards{
+		occs:    make([][]cardOcc, 2*(capHint+1)),
+		counted: make([]bool, capHint+1)}
+}
+
+// Copy returns a copy of x.
+func (x *cards) Copy() *cards {
+	if x == nil {
+		return nil
+	}
+	other := &cards{
+		cs:          make([]card, len(x.cs)),
+		occs:        make([][]cardOcc, len(x.occs)),
+		counted:     append([]bool(nil), x.counted...),
+		pending:     append([]int(nil), x.pending...),
+		head:        x.head,
+		stCards:     x.stCards,
+		stProps:     x.stProps,
+		stConflicts: x.stConflicts}
+	for i := range x.cs {
+		other.cs[i] = x.cs[i]
+		other.cs[i].ms = append([]z.Lit(nil), x.cs[i].ms...)
+		other.cs[i].ws = append([]int(nil), x.cs[i].ws...)
+	}
+	for i := range x.occs {
+		other.occs[i] = append([]cardOcc(nil), x.occs[i]...)
+	}
+	return other
+}
+
+func (x *cards) growToVar(u z.Var) {
+	w := u + 1
+	occs := make([][]cardOcc, 2*w)
+	copy(occs, x.occs)
+	x.occs = occs
+	counted := make([]bool, w)
+	copy(counted, x.counted)
+	x.counted = counted
+}
+
+func (x *cards) readStats(st *Stats) {
+	st.Cards += x.stCards
+	x.stCards = 0
+	st.CardProps += x.stProps
+	x.stProps = 0
+	st.CardConflicts += x.stConflicts
+	x.stConflicts = 0
+}
+
+// add adds the constraint that the sum of the weights ws of the true
+// literals of ms is at most k, where ms has distinct variables, k > 0, the
+// weights are positive and at most k, and their sum exceeds k.
+func (x *cards) add(vals []int8, ms []z.Lit, ws []int, k int) {
+	i := len(x.cs)
+	c := card{ms: ms, ws: ws, k: k}
+	sort.Stable(c)
+	for j, m := range ms {
+		x.occs[m] = append(x.occs[m], cardOcc{i: i, w: ws[j]})
+		if vals[m] == 1 && x.counted[m.Var()] {
+			c.n += ws[j]
+		}
+	}
+	x.cs = append(x.cs, c)
+	x.queue(i)
+	x.stCards++
+}
+
+// sat returns whether the total assignment vals satisfies the
+// constraints of x.
+func (x *cards) sat(vals []int8) bool {
+	for i := range x.cs {
+		c := &x.cs[i]
+		n := 0
+		for j, m := range c.ms {
+			if vals[m] == 1 {
+				n += c.ws[j]
+			}
+		}
+		if n > c.k {
+			return false
+		}
+	}
+	return true
+}
+
+// queue schedules constraint i to be checked.
+func (x *cards) queue(i int) {
+	if x.cs[i].pending {
+		return
+	}
+	x.cs[i].pending = true
+	x.pending = append(x.pending, i)
+}
+
+// ready returns whether x has constraints to check or assignments to
+// process.
+func (x *cards) ready(t *Trail) bool {
+	return len(x.pending) > 0 || x.head < t.Tail
+}
+
+// unassign is called by backtracking when the true literal m is
+// unassigned.
+func (x *cards) unassign(m z.Lit) {
+	v := m.Var()
+	if !x.counted[v] {
+		return
+	}
+	x.counted[v] = false
+	for _, o := range x.occs[m] {
+		x.cs[o.i].n -= o.w
+	}
+}
+
+// prop processes the assignments of the trail t since the last call,
+// summing the weights of true literals and checking the constraints whose
+// slack falls below their greatest weight.  prop returns CNull or a
+// conflict.
+func (x *cards) prop(t *Trail) z.C {
+	for {
+		for len(x.pending) > 0 {
+			i := x.pending[len(x.pending)-1]
+			x.pending = x.pending[:len(x.pending)-1]
+			x.cs[i].pending = false
+			if p := x.check(t, i); p != CNull {
+				// check again after backtracking.
+				x.queue(i)
+				return p
+			}
+		}
+		if x.head >= t.Tail {
+			return CNull
+		}
+		m := t.D[x.head]
+		x.head++
+		if v := m.Var(); !x.counted[v] {
+			x.counted[v] = true
+			for _, o := range x.occs[m] {
+				x.cs[o.i].n += o.w
+			}
+		}
+		// literals kept by chronological backtracking are already
+		// counted, but the literals they implied may have been
+		// unassigned.
+		for _, o := range x.occs[m] {
+			if c := &x.cs[o.i]; c.n+c.ws[0] > c.k {
+				x.queue(o.i)
+			}
+		}
+	}
+}
+
+// check checks constraint i, implying false its unassigned literals whose
+// weights exceed its slack and returning a conflict if its slack is
+// negative.
+func (x *cards) check(t *Trail, i int) z.C {
+	c := &x.cs[i]
+	if c.n+c.ws[0] <= c.k {
+		return CNull
+	}
+	vals := t.Vars.Vals
+	// ts holds the negations of the counted true literals, heaviest
+	// first, and sums[j] the sum of the weights of ts[:j].
+	ts := x.ms[:0]
+	sums := append(x.sums[:0], 0)
+	for j, m := range c.ms {
+		if vals[m] == 1 && x.counted[m.Var()] {
+			ts = append(ts, m.Not())
+			sums = append(sums, sums[len(sums)-1]+c.ws[j])
+		}
+	}
+	x.ms, x.sums = ts, sums
+	// the reasons follow ts in x.ms.
+	if c.n > c.k {
+		x.stConflicts++
+		j := 1
+		for sums[j] <= c.k {
+			j++
+		}
+		ms := append(ts[len(ts):], ts[:j]...)
+		x.watchHighest(t, ms, 0)
+		return t.Cdb.addLearnt(ms, len(ms)-1, nil)
+	}
+	j := 0
+	for l, m := range c.ms {
+		w := c.ws[l]
+		if c.n+w <= c.k {
+			// the weights decrease.
+			break
+		}
+		if vals[m] != 0 {
+			continue
+		}
+		// the true literals of ts[:j] and m exceed k.
+		for sums[j]+w <= c.k {
+			j++
+		}
+		ms := append(ts[len(ts):], m.Not())
+		ms = append(ms, ts[:j]...)
+		x.watchHighest(t, ms, 1)
+		x.stProps++
+		t.Assign(m.Not(), t.Cdb.addLearnt(ms, len(ms)-1, nil))
+	}
+	return CNull
+}
+
+// watchHighest moves the highest level literals of ms from position start
+// on to the watched positions, before position 2.
+func (x *cards) watchHighest(t *Trail, ms []z.Lit, start int) {
+	lvls := t.Vars.Levels
+	for j := start; j < 2 && j < len(ms); j++ {
+		h := j
+		for k := j + 1; k < len(ms); k++ {
+			if lvls[ms[k].Var()] > lvls[ms[h].Var()] {
+				h = k
+			}
+		}
+		ms[j], ms[h] = ms[h], ms[j]
+	}
+}
+
+// Len, Less and Swap sort the literals of c by decreasing weight.
+func (c card) Len() int {
+	return len(c.ms)
+}
+
+func (c card) Less(i, j int) bool {
+	return c.ws[i] > c.ws[j]
+}
+
+func (c card) Swap(i, j int) {
+	c.ms[i], c.ms[j] = c.ms[j], c.ms[i]
+	c.ws[i], c.ws[j] = c.ws[j], c.ws[i]
+}
+
+// AddAtMost adds the constraint that at most k of the literals in ms are
+// true.  A literal occurring more than once in ms counts once for each
+// occurrence.  The variables of ms are frozen, see Freeze.
+//
+// Cardinality constraints are propagated natively, except when a proof is
+// written: then they are added as clauses, so that the proof covers them.
+// Otherwise, they are not written by Write.
+func (s *S) AddAtMost(ms []z.Lit, k int) {
+	ws := make([]int, len(ms))
+	for i := range ws {
+		ws[i] = 1
+	}
+	s.addLinear(ms, ws, k)
+}
+
+// AddAtLeast adds the constraint that at least k of the literals in ms
+// are true, as AddAtMost of their negations.
+func (s *S) AddAtLeast(ms []z.Lit, k int) {
+	ns := make([]z.Lit, len(ms))
+	for i, m := range ms {
+		ns[i] = m.Not()
+	}
+	s.AddAtMost(ns, len(ms)-k)
+}
+
+// AddPb adds the pseudo-Boolean constraint that the sum of the weights
+// ws[i] of the true literals ms[i] is at least k.  Weights may be
+// negative.  The variables of ms are frozen, see Freeze.
+//
+// Pseudo-Boolean constraints are propagated as cardinality constraints,
+// see AddAtMost.
+func (s *S) AddPb(ms []z.Lit, ws []int, k int) {
+	ns := make([]int, len(ws))
+	for i, w := range ws {
+		ns[i] = -w
+	}
+	s.addLinear(ms, ns, -k)
+}
+
+// addLinear adds the constraint that the sum of the weights ws of the true
+// literals of ms is at most k.
+func (s *S) addLinear(ms []z.Lit, ws []int, k int) {
+	for _, m := range ms {
+		s.ensureLitCap(m)
+	}
+	s.ensure0()
+	// normalize to distinct variables with positive weights, using
+	// w*m = w - w*m.Not().
+	vws := make(map[z.Var]int, len(ms))
+	vs := make([]z.Var, 0, len(ms))
+	for i, m := range ms {
+		v, w := m.Var(), ws[i]
+		if _, ok := vws[v]; !ok {
+			vs = append(vs, v)
+		}
+		if !m.IsPos() {
+			k -= w
+			w = -w
+		}
+		vws[v] += w
+	}
+	ns := make([]z.Lit, 0, len(vs))
+	nws := make([]int, 0, len(vs))
+	sum := 0
+	for _, v := range vs {
+		m, w := v.Pos(), vws[v]
+		switch {
+		case w == 0:
+			continue
+		case w < 0:
+			k -= w
+			m, w = m.Not(), -w
+		}
+		ns = append(ns, m)
+		nws = append(nws, w)
+		sum += w
+	}
+	if k < 0 {
+		s.Add(z.LitNull)
+		return
+	}
+	// literals heavier than k are false.
+	j := 0
+	for i, m := range ns {
+		if nws[i] > k {
+			s.Add(m.Not())
+			s.Add(z.LitNull)
+			sum -= nws[i]
+			continue
+		}
+		ns[j], nws[j] = m, nws[i]
+		j++
+	}
+	ns, nws = ns[:j], nws[:j]
+	if sum <= k {
+		return
+	}
+	for _, m := range ns {
+		s.Freeze(m.Var())
+	}
+	if s.Cdb.Tracer != nil {
+		s.addLinearClauses(ns, nws, k)
+		return
+	}
+	if s.cards == nil {
+		s.cards = newCards(int(s.Vars.Top))
+		s.Trail.cards = s.cards
+	}
+	s.cards.add(s.Vars.Vals, ns, nws, k)
+}
+
+// addLinearClauses adds the constraint that the sum of the weights ws of
+// the true literals of ms is at most k as the clauses of a sequential
+// weight counter, whose fresh variable for position i and sum j states
+// that the weights of the true literals of ms[:i+1] sum to more than j.
+func (s *S) addLinearClauses(ms []z.Lit, ws []int, k int) {
+	add := func(ns ...z.Lit) {
+		for _, n := range ns {
+			s.Add(n)
+		}
+		s.Add(z.LitNull)
+	}
+	n := len(ms)
+	var prev []z.Lit
+	for i, m := range ms[:n-1] {
+		w := ws[i]
+		cur := make([]z.Lit, k)
+		for j := range cur {
+			cur[j] = s.Lit()
+		}
+		for j := 0; j < w; j++ {
+			add(m.Not(), cur[j])
+		}
+		if prev != nil {
+			for j := 0; j < k; j++ {
+				add(prev[j].Not(), cur[j])
+				if j+w < k {
+					add(m.Not(), prev[j].Not(), cur[j+w])
+				}
+			}
+			add(m.Not(), prev[k-w].Not())
+		}
+		prev = cur
+	}
+	add(ms[n-1].Not(), prev[k-ws[n-1]].Not())
+}
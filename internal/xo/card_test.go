@@ -0,0 +1,334 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-air/gini/z"
+)
+
+// cardCons is the constraint that the sum of the weights ws, or 1 if ws is
+// nil, of the true literals of ms is at most k.
+type cardCons struct {
+	ms []z.Lit
+	ws []int
+	k  int
+}
+
+func (c *cardCons) weight(i int) int {
+	if c.ws == nil {
+		return 1
+	}
+	return c.ws[i]
+}
+
+// randCards returns m random at most k constraints over n variables with
+// between 2 and 12 literals each, some of them repeated or complementary.
+func randCards(rng *rand.Rand, n, m int) []cardCons {
+	var cs []cardCons
+	for i := 0; i < m; i++ {
+		l := 2 + rng.Intn(11)
+		ms := make([]z.Lit, l)
+		for j := range ms {
+			ms[j] = z.Var(rng.Intn(n) + 1).Pos()
+			if rng.Intn(2) == 0 {
+				ms[j] = ms[j].Not()
+			}
+		}
+		cs = append(cs, cardCons{ms: ms, k: rng.Intn(l)})
+	}
+	return cs
+}
+
+// randPbs returns m random pseudo-Boolean constraints like randCards,
+// with weights between -maxW and maxW, stated as at least k.
+func randPbs(rng *rand.Rand, n, m, maxW int) []cardCons {
+	cs := randCards(rng, n, m)
+	for i := range cs {
+		c := &cs[i]
+		c.ws = make([]int, len(c.ms))
+		sum := 0
+		for j := range c.ws {
+			c.ws[j] = rng.Intn(2*maxW+1) - maxW
+			if c.ws[j] > 0 {
+				sum += c.ws[j]
+			}
+		}
+		c.k = rng.Intn(sum/2 + 1)
+	}
+	return cs
+}
+
+// atMost returns the at most constraint equivalent to the at least
+// constraint c.
+func (c *cardCons) atMost() cardCons {
+	// w*m = w + |w|*m.Not() for negative w.
+	k := c.k
+	var ms []z.Lit
+	var ws []int
+	for i, m := range c.ms {
+		w := c.weight(i)
+		if w < 0 {
+			k -= w
+			m, w = m.Not(), -w
+		}
+		ms = append(ms, m)
+		ws = append(ws, w)
+	}
+	// the weights of the literals are at least k iff the weights of
+	// their negations are at most the sum of the weights less k.
+	sum := 0
+	for i := range ms {
+		ms[i] = ms[i].Not()
+		sum += ws[i]
+	}
+	return cardCons{ms: ms, ws: ws, k: sum - k}
+}
+
+func checkCards(t *testing.T, s *S, cs []cardCons) {
+	for _, c := range cs {
+		n := 0
+		for i, m := range c.ms {
+			if s.Value(m) {
+				n += c.weight(i)
+			}
+		}
+		if n > c.k {
+			t.Fatalf("model does not satisfy %v %v <= %d", c.ms, c.ws, c.k)
+		}
+	}
+}
+
+// cardClauses returns a solver with cnf and cs added as clauses, one for
+// each minimal set of occurrences of literals of a constraint whose
+// weights exceed k.
+func cardClauses(cnf [][]z.Lit, cs []cardCons) *S {
+	o := NewS()
+	addCnf(o, cnf)
+	for _, c := range cs {
+		for set := 0; set < 1<<uint(len(c.ms)); set++ {
+			sum, min := 0, -1
+			for i := range c.ms {
+				if set&(1<<uint(i)) == 0 {
+					continue
+				}
+				w := c.weight(i)
+				sum += w
+				if min == -1 || w < min {
+					min = w
+				}
+			}
+			if sum <= c.k || (min != -1 && sum-min > c.k) {
+				continue
+			}
+			for i, m := range c.ms {
+				if set&(1<<uint(i)) != 0 {
+					o.Add(m.Not())
+				}
+			}
+			o.Add(z.LitNull)
+		}
+	}
+	return o
+}
+
+func TestCard(t *testing.T) {
+	rng := rand.New(rand.NewSource(19))
+	st := NewStats()
+	sats := 0
+	for i := 0; i < 60; i++ {
+		cnf := elimCnf(rng, 60, 100+rng.Intn(100))
+		cs := randCards(rng, 60, 10+rng.Intn(30))
+		s := NewS()
+		if i%2 == 1 {
+			s.SetOptions(&Options{Chrono: true, ChronoLevels: 1})
+		}
+		addCnf(s, cnf)
+		for _, c := range cs {
+			s.AddAtMost(c.ms, c.k)
+		}
+		o := cardClauses(cnf, cs)
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Fatalf("card result %d", r)
+		}
+		if r == 1 {
+			sats++
+			checkCnf(t, s, cnf)
+			checkCards(t, s, cs)
+		}
+		s.ReadStats(st)
+	}
+	if st.CardProps == 0 || st.CardConflicts == 0 {
+		t.Errorf("no card propagation: %d/%d", st.CardProps, st.CardConflicts)
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+}
+
+func TestCardIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(23))
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 40, 60)
+		cs := randCards(rng, 40, 15)
+		s := NewS()
+		addCnf(s, cnf)
+		for _, c := range cs {
+			s.AddAtMost(c.ms, c.k)
+		}
+		for j := 0; j < 6; j++ {
+			a := z.Var(rng.Intn(40) + 1).Pos()
+			b := z.Var(rng.Intn(40) + 1).Neg()
+			o := cardClauses(cnf, cs)
+			o.Assume(a, b)
+			want := o.Solve()
+			s.Assume(a)
+			if r, _ := s.Test(nil); r == -1 {
+				if want != -1 {
+					t.Fatalf("test unsat under %s", a)
+				}
+				s.Untest()
+				continue
+			}
+			s.Assume(b)
+			r := s.Solve()
+			if r != want {
+				t.Fatalf("incremental card result %d", r)
+			}
+			if r == 1 {
+				checkCnf(t, s, cnf)
+				checkCards(t, s, cs)
+				if !s.Value(a) || !s.Value(b) {
+					t.Fatalf("assumption false")
+				}
+			}
+			s.Untest()
+			if j == 3 {
+				// more constraints between solves.
+				more := randCards(rng, 40, 3)
+				for _, c := range more {
+					s.AddAtMost(c.ms, c.k)
+				}
+				cs = append(cs, more...)
+			}
+		}
+	}
+}
+
+func TestCardPhp(t *testing.T) {
+	// n+1 pigeons in n holes, with at least one hole per pigeon and at
+	// most one pigeon per hole.
+	n := 7
+	v := func(p, h int) z.Lit {
+		return z.Var(p*n + h + 1).Pos()
+	}
+	s := NewS()
+	for p := 0; p <= n; p++ {
+		ms := make([]z.Lit, n)
+		for h := range ms {
+			ms[h] = v(p, h)
+		}
+		s.AddAtLeast(ms, 1)
+	}
+	for h := 0; h < n; h++ {
+		ms := make([]z.Lit, n+1)
+		for p := range ms {
+			ms[p] = v(p, h)
+		}
+		s.AddAtMost(ms, 1)
+	}
+	if s.Solve() != -1 {
+		t.Errorf("php sat")
+	}
+	st := NewStats()
+	s.ReadStats(st)
+	if st.Cards != int64(2*n+1) {
+		t.Errorf("cards: %d", st.Cards)
+	}
+}
+
+func TestPb(t *testing.T) {
+	rng := rand.New(rand.NewSource(31))
+	st := NewStats()
+	sats := 0
+	for i := 0; i < 60; i++ {
+		cnf := elimCnf(rng, 50, 60+rng.Intn(80))
+		pbs := randPbs(rng, 50, 5+rng.Intn(20), 1+rng.Intn(8))
+		s := NewS()
+		if i%2 == 1 {
+			s.SetOptions(&Options{Chrono: true, ChronoLevels: 1})
+		}
+		addCnf(s, cnf)
+		cs := make([]cardCons, len(pbs))
+		for j, c := range pbs {
+			s.AddPb(c.ms, c.ws, c.k)
+			cs[j] = c.atMost()
+		}
+		r := s.Solve()
+		if r != cardClauses(cnf, cs).Solve() {
+			t.Fatalf("pb result %d", r)
+		}
+		if r == 1 {
+			sats++
+			checkCnf(t, s, cnf)
+			checkCards(t, s, cs)
+		}
+		s.ReadStats(st)
+	}
+	if st.CardProps == 0 || st.CardConflicts == 0 {
+		t.Errorf("no pb propagation: %d/%d", st.CardProps, st.CardConflicts)
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+}
+
+func TestCardClauses(t *testing.T) {
+	rng := rand.New(rand.NewSource(29))
+	for i := 0; i < 40; i++ {
+		cnf := elimCnf(rng, 40, 60+rng.Intn(60))
+		var cs []cardCons
+		for _, c := range randCards(rng, 40, 5+rng.Intn(15)) {
+			// addLinearClauses requires distinct variables and
+			// positive weights which are at most k and sum to more
+			// than k.
+			seen := make(map[z.Var]bool)
+			ms := c.ms[:0]
+			var ws []int
+			sum := 0
+			for _, m := range c.ms {
+				if seen[m.Var()] {
+					continue
+				}
+				w := 1 + rng.Intn(4)
+				if w > c.k {
+					w = c.k
+				}
+				seen[m.Var()] = true
+				ms = append(ms, m)
+				ws = append(ws, w)
+				sum += w
+			}
+			if c.k > 0 && sum > c.k {
+				cs = append(cs, cardCons{ms: ms, ws: ws, k: c.k})
+			}
+		}
+		s := NewS()
+		addCnf(s, cnf)
+		for _, c := range cs {
+			s.addLinearClauses(c.ms, c.ws, c.k)
+		}
+		r := s.Solve()
+		if r != cardClauses(cnf, cs).Solve() {
+			t.Fatalf("card clauses result %d", r)
+		}
+		if r == 1 {
+			checkCnf(t, s, cnf)
+			checkCards(t, s, cs)
+		}
+	}
+}
@@ -25,11 +25,19 @@ type Cdb struct {
 	Learnts []z.C
 
 	Tracer     Tracer
+	traceHints bool // whether the Tracer is given hints
+	trLits     []z.Lit
+	trHints    []z.C
 	checkModel bool
 
 	// for multi-scheduling gc frequency
 	gc *Cgc
 
+	// learnt clause export, if any
+	export    func(ms []z.Lit, lbd int)
+	exportLen int
+	exportLbd int
+
 	// stats
 	stAdds         int64
 	stAddUnit      int64
@@ -40,6 +48,7 @@ type Cdb struct {
 	stLitAdds      int64
 	stMinLits      int64
 	stHeatRescales int64
+	stExported     int64
 }
 
 func NewCdb(v *Vars, capHint int) *Cdb {
@@ -82,7 +91,11 @@ func (c *Cdb) readStats(st *Stats) {
 	st.CHeatRescales = c.stHeatRescales
 	c.stHeatRescales = 0
 
+	st.Exported += c.stExported
+	c.stExported = 0
+
 	st.Learnts = len(c.Learnts)
+	st.CoreLearnts, st.Tier2Learnts, st.LocalLearnts = c.gc.tierSizes(c)
 
 	c.gc.readStats(st)
 }
@@ -103,6 +116,8 @@ func (c *Cdb) Add(m z.Lit) (z.C, z.Lit) {
 	vars := c.Vars
 	w := vars.Watches
 	var n z.Lit
+	shortened := false
+	fLits := c.trLits[:0]
 	for _, m := range ms {
 		mv := m.Var()
 		// TODO(wsc) make this work for tests without duplicating op in Solver.Add()
@@ -122,15 +137,46 @@ func (c *Cdb) Add(m z.Lit) (z.C, z.Lit) {
 			retLoc = CInf
 			c.stAddFails++
 			c.stMinLits += int64(len(ms))
+			if c.Tracer != nil {
+				c.Tracer.Input(CInf)
+			}
 			goto Done
 		}
-		if us == -1 || as == 1 {
+		if us == -1 {
+			shortened = true
+			if c.traceHints && !hasLit(fLits, m) {
+				// each unit is a hint at most once.
+				fLits = append(fLits, m)
+			}
+			continue
+		}
+		if as == 1 {
 			continue
 		}
 		panic("unreachable")
 	}
 	c.stMinLits += int64(len(ms) - j)
-	retLoc = c.CDat.AddLits(MakeChd(false, 0, j), ms[0:j])
+	if shortened && c.Tracer != nil {
+		// the clause minus false literals is derived, not given.
+		c.Tracer.Input(CInf)
+		var hints []z.C
+		if c.traceHints {
+			hints = c.trHints[:0]
+			for _, f := range fLits {
+				hints = append(hints, c.rootUnit(f.Not()))
+			}
+			hints = append(hints, CInf)
+			c.trHints = hints
+		}
+		retLoc = c.CDat.AddLits(MakeChd(false, 0, j), ms[0:j])
+		c.Tracer.Add(retLoc, ms[0:j], hints)
+	} else {
+		retLoc = c.CDat.AddLits(MakeChd(false, 0, j), ms[0:j])
+		if c.Tracer != nil {
+			c.Tracer.Input(retLoc)
+		}
+	}
+	c.trLits = fLits[:0]
 	c.Added = append(c.Added, retLoc)
 	if j == 0 {
 		c.Bot = retLoc
@@ -166,26 +212,50 @@ Done:
 	return retLoc, retLit
 }
 
+// hasLit returns whether ms contains m.
+func hasLit(ms []z.Lit, m z.Lit) bool {
+	for _, n := range ms {
+		if n == m {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cdb) Remove(cs ...z.C) {
 	c.gc.Remove(c, cs...)
 }
 
 func (c *Cdb) Learn(ms []z.Lit, lbd int) z.C {
-	ret := c.CDat.AddLits(MakeChd(true, lbd, len(ms)), ms)
-	if c.Active != nil {
-		is := c.Active.IsActive
-		occs := c.Active.Occs
-		for _, m := range ms {
-			mv := m.Var()
-			if !is[mv] {
-				continue
-			}
-			if m.IsPos() {
-				panic("positive act lit")
-			}
-			occs[mv] = append(occs[mv], ret)
+	return c.learn(ms, lbd, nil)
+}
+
+// learn adds the learnt clause ms, which follows from the clauses in hints
+// as described in Tracer, counting it as a conflict for scheduling
+// reductions.  learn passes ms to the export function, if any.
+func (c *Cdb) learn(ms []z.Lit, lbd int, hints []z.C) z.C {
+	ret := c.addLearnt(ms, lbd, hints)
+	c.gc.Tick()
+	if c.export != nil && len(ms) <= c.exportLen {
+		if lbd > len(ms)-1 {
+			lbd = len(ms) - 1
 		}
+		if lbd <= c.exportLbd {
+			c.stExported++
+			c.export(ms, lbd)
+		}
+	}
+	return ret
+}
+
+// addLearnt adds the learnt clause ms as learn does, without counting
+// a conflict.
+func (c *Cdb) addLearnt(ms []z.Lit, lbd int, hints []z.C) z.C {
+	ret := c.CDat.AddLits(MakeChd(true, lbd, len(ms)), ms)
+	if c.Tracer != nil {
+		c.Tracer.Add(ret, ms, hints)
 	}
+	c.addOccs(ret, ms)
 	msLen := len(ms)
 	switch msLen {
 	case 0:
@@ -200,10 +270,91 @@ func (c *Cdb) Learn(ms []z.Lit, lbd int) z.C {
 		w[n] = append(w[n], MakeWatch(ret, m, msLen == 2))
 	}
 	c.Learnts = append(c.Learnts, ret)
-	c.gc.Tick()
 	return ret
 }
 
+// strengthen adds the clause ms, a subset of the clause p, as a learnt
+// clause if p is learnt and as an added clause otherwise.  The caller
+// should remove p.
+func (c *Cdb) strengthen(p z.C, ms []z.Lit) z.C {
+	h := c.CDat.Chd(p)
+	lbd := int(h.Lbd())
+	if lbd > len(ms)-1 {
+		lbd = len(ms) - 1
+	}
+	ret := c.CDat.AddLits(MakeChd(h.Learnt(), lbd, len(ms)), ms)
+	if c.Tracer != nil {
+		c.Tracer.Add(ret, ms, nil)
+	}
+	c.addOccs(ret, ms)
+	if len(ms) > 1 {
+		c.link(ret, ms)
+	}
+	if h.Learnt() {
+		c.Learnts = append(c.Learnts, ret)
+	} else {
+		c.Added = append(c.Added, ret)
+	}
+	return ret
+}
+
+// addOccs records the occurrences of activation literals in the learnt
+// clause p with literals ms.
+func (c *Cdb) addOccs(p z.C, ms []z.Lit) {
+	if c.Active == nil {
+		return
+	}
+	is := c.Active.IsActive
+	occs := c.Active.Occs
+	for _, m := range ms {
+		mv := m.Var()
+		if !is[mv] {
+			continue
+		}
+		if m.IsPos() {
+			panic("positive act lit")
+		}
+		occs[mv] = append(occs[mv], p)
+	}
+}
+
+// link adds watches for the clause p with literals ms, which should be
+// in the order of the clause data.
+func (c *Cdb) link(p z.C, ms []z.Lit) {
+	w := c.Vars.Watches
+	m, n := ms[0], ms[1]
+	w[m] = append(w[m], MakeWatch(p, n, len(ms) == 2))
+	w[n] = append(w[n], MakeWatch(p, m, len(ms) == 2))
+}
+
+// watchAt moves the literal m of the clause p to position i, 0 or 1,
+// which is watched, updating the watches if m was not watched.
+func (c *Cdb) watchAt(p z.C, i int, m z.Lit) {
+	d := c.CDat.D
+	q := p
+	for d[q] != m {
+		q++
+	}
+	o := p + z.C(i)
+	if q == o {
+		return
+	}
+	if q > p+1 {
+		w := c.Vars.Watches
+		n := d[o]
+		ws := w[n]
+		for j, x := range ws {
+			if x.C() == p {
+				copy(ws[j:], ws[j+1:])
+				w[n] = ws[:len(ws)-1]
+				break
+			}
+		}
+		w[m] = append(w[m], MakeWatch(p, d[p+1-z.C(i)], false))
+	}
+	d[o], d[q] = d[q], d[o]
+}
+
 func (c *Cdb) InUse(o z.C) bool {
 	m := c.CDat.D[o]
 	return m != z.LitNull && c.Vars.Reasons[m.Var()] == o
@@ -234,6 +385,9 @@ func (c *Cdb) Bump(p z.C) {
 		c.stHeatRescales++
 		c.CDat.bumpInc /= 2
 	}
+	if c.CDat.Chd(p).Learnt() {
+		c.gc.use(c, p)
+	}
 }
 
 func (c *Cdb) Decay() {
@@ -301,6 +455,69 @@ func (c *Cdb) Unlink(rms []z.C) {
 
 func (c *Cdb) SetTracer(t Tracer) {
 	c.Tracer = t
+	c.traceHints = false
+}
+
+// SetHintTracer is like SetTracer, but also causes the tracer to be given
+// hints for derived clauses.
+func (c *Cdb) SetHintTracer(t Tracer) {
+	c.Tracer = t
+	c.traceHints = t != nil
+}
+
+// rootUnit returns the location of a unit clause containing m, which must be
+// true at decision level 0, learning it if necessary.
+func (c *Cdb) rootUnit(m z.Lit) z.C {
+	vars := c.Vars
+	v := m.Var()
+	p := vars.Reasons[v]
+	if c.IsUnit(p) {
+		return p
+	}
+	// the reason for m at level 0 may have other literals, which are
+	// false at level 0.
+	var ns []z.Lit
+	ns = c.Lits(p, ns)
+	hints := make([]z.C, 0, len(ns))
+	for _, n := range ns {
+		if n == m {
+			continue
+		}
+		hints = append(hints, c.rootUnit(n.Not()))
+	}
+	hints = append(hints, p)
+	q := c.learn([]z.Lit{m}, 1, hints)
+	vars.Reasons[v] = q
+	return q
+}
+
+// rootHints returns hints for deriving the empty clause from x, which is
+// false at decision level 0, or nil if no hints are traced.
+func (c *Cdb) rootHints(x z.C) []z.C {
+	if !c.traceHints {
+		return nil
+	}
+	var ms []z.Lit
+	ms = c.Lits(x, ms)
+	hints := make([]z.C, 0, len(ms)+1)
+	for _, m := range ms {
+		hints = append(hints, c.rootUnit(m.Not()))
+	}
+	return append(hints, x)
+}
+
+// traceRemove informs the tracer, if any, that the clauses
+// ps are removed.  The clause data must still be present.
+func (c *Cdb) traceRemove(ps []z.C) {
+	if c.Tracer == nil {
+		return
+	}
+	ms := c.trLits
+	for _, p := range ps {
+		ms = c.Lits(p, ms[:0])
+		c.Tracer.Remove(p, ms)
+	}
+	c.trLits = ms[:0]
 }
 
 func (c *Cdb) Write(w io.Writer) error {
@@ -9,15 +9,47 @@ import (
 	"github.com/go-air/gini/z"
 )
 
+const (
+	cgcFactor      = 2048
+	cgcFraction    = 0.5
+	cgcTierCore    = 2
+	cgcTier2       = 6
+	cgcTier2Window = 10000
+)
+
+// learnt clause tiers
+const (
+	tierCore = iota
+	tier2
+	tierLocal
+)
+
 // Type Cgc encapsulates clause compaction/garbage collection.
 //
 // This is separate from, and sometimes calls CDat compaction
 // which is another issue.
+//
+// Learnt clauses are kept in three tiers according to their glue, the
+// number of decision levels of their literals when learnt or last used.
+// Core clauses are kept forever, tier2 clauses are kept while they have
+// been used in the last tier2Window conflicts, and the others are local
+// and a fraction of those unused since the last compaction are removed at
+// each compaction.
 type Cgc struct {
 	luby      *Luby
 	factor    uint
+	fraction  float64
 	stopWatch uint // make int and regularize diff between tick and compact?
 
+	tierCore    uint32
+	tier2       uint32
+	tier2Window int64
+	conflicts   int64
+	lastCompact int64
+	used        map[z.C]int64 // conflict count of last use of non-core learnts
+	lvlStamps   []int64
+	lvlStamp    int64
+
 	rmq []z.C
 
 	rmLits int
@@ -34,9 +66,16 @@ type Cgc struct {
 func NewCgc() *Cgc {
 	l := NewLuby()
 	return &Cgc{
-		luby:       l,
-		factor:     2048,
-		stopWatch:  2048 * l.Next(),
+		luby:      l,
+		factor:    cgcFactor,
+		fraction:  cgcFraction,
+		stopWatch: cgcFactor * l.Next(),
+
+		tierCore:    cgcTierCore,
+		tier2:       cgcTier2,
+		tier2Window: cgcTier2Window,
+		used:        make(map[z.C]int64, 1024),
+
 		rmq:        make([]z.C, 0, 1024),
 		rmLits:     0,
 		rmd:        0,
@@ -52,18 +91,100 @@ func (c *Cgc) Copy() *Cgc {
 	*l = *c.luby
 	other := &Cgc{
 		luby:      l,
-		factor:    2048,
-		stopWatch: 2048 * l.Next(),
-		rmq:       make([]z.C, len(c.rmq), cap(c.rmq)),
-		rmLits:    c.rmLits,
-		rmd:       c.rmd}
+		factor:    c.factor,
+		fraction:  c.fraction,
+		stopWatch: c.factor * l.Next(),
+
+		tierCore:    c.tierCore,
+		tier2:       c.tier2,
+		tier2Window: c.tier2Window,
+		conflicts:   c.conflicts,
+		lastCompact: c.lastCompact,
+		used:        make(map[z.C]int64, len(c.used)),
+
+		rmq:    make([]z.C, len(c.rmq), cap(c.rmq)),
+		rmLits: c.rmLits,
+		rmd:    c.rmd}
 	copy(other.rmq, c.rmq)
+	for p, t := range c.used {
+		other.used[p] = t
+	}
 	return other
 }
 
+// setOptions sets the reduction schedule of c.
+func (c *Cgc) setOptions(opts *Options) {
+	c.factor = opts.ReduceFactor
+	c.fraction = opts.ReduceFraction
+	c.tierCore = uint32(opts.TierCore)
+	c.tier2 = uint32(opts.Tier2)
+	c.tier2Window = int64(opts.Tier2Window)
+}
+
+// tier returns the tier of a learnt clause with header h.
+func (c *Cgc) tier(h Chd) int {
+	glue := h.Lbd() + 1 // Chd lbd does not count the uip level
+	switch {
+	case glue <= c.tierCore:
+		return tierCore
+	case glue <= c.tier2:
+		return tier2
+	default:
+		return tierLocal
+	}
+}
+
+// use is called when the learnt clause p is used in conflict analysis.
+// use records the use and lowers the lbd of p if its literals are now on
+// fewer levels.
+func (c *Cgc) use(cdb *Cdb, p z.C) {
+	h := cdb.CDat.Chd(p)
+	if c.tier(h) == tierCore {
+		return
+	}
+	c.used[p] = c.conflicts
+	levels := cdb.Vars.Levels
+	if len(c.lvlStamps) < len(levels) {
+		c.lvlStamps = make([]int64, len(levels))
+	}
+	c.lvlStamp++
+	lbd := uint32(0)
+	D := cdb.CDat.D
+	for q := p; D[q] != z.LitNull; q++ {
+		l := levels[D[q].Var()]
+		if l <= 0 || c.lvlStamps[l] == c.lvlStamp {
+			continue
+		}
+		c.lvlStamps[l] = c.lvlStamp
+		lbd++
+	}
+	if lbd == 0 {
+		return
+	}
+	if lbd-1 < h.Lbd() {
+		cdb.CDat.SetChd(p, h.SetLbd(lbd-1))
+	}
+}
+
+// tierSizes returns the number of learnt clauses in each tier.
+func (c *Cgc) tierSizes(cdb *Cdb) (core, t2, local int) {
+	for _, p := range cdb.Learnts {
+		switch c.tier(cdb.CDat.Chd(p)) {
+		case tierCore:
+			core++
+		case tier2:
+			t2++
+		default:
+			local++
+		}
+	}
+	return
+}
+
 // Tick is called every time there is a learned clause.
 // it keeps track of virtual time for the gc.
 func (c *Cgc) Tick() {
+	c.conflicts++
 	if c.stopWatch > 0 {
 		c.stopWatch--
 	}
@@ -75,10 +196,20 @@ func (c *Cgc) Ready() bool {
 }
 
 func (gc *Cgc) Remove(cdb *Cdb, cs ...z.C) {
+	cdb.traceRemove(cs)
+	gc.remove(cdb, cs...)
+}
+
+// remove removes the clauses cs as Remove does, without informing the
+// tracer.
+func (gc *Cgc) remove(cdb *Cdb, cs ...z.C) {
 	rmLitCount := 0
 	for _, c := range cs {
 		rmLitCount += cdb.Size(c)
 	}
+	for _, p := range cs {
+		delete(gc.used, p)
+	}
 
 	gc.rmq = append(gc.rmq, cs...)
 	gc.rmd += len(cs)
@@ -123,19 +254,23 @@ func (c *Cgc) Compact(cdb *Cdb) (int, int, int) {
 	c.stopWatch = c.luby.Next() * c.factor
 	learnts := cdb.Learnts
 	cDat := cdb.CDat
-	g := gcLearnts{
-		learnts: learnts,
-		cdat:    &cDat}
-	g.Sort()
 
-	sz := len(cdb.Learnts)
-	top := sz - 1
-	lim := sz / 2
-	rmLitCount := 0
-	rms := make([]z.C, 0, lim)
-	// maybe should go in ascending order...--nope!
-	for n := top; n >= 0; n-- {
-		p := learnts[n]
+	// candidates are local clauses unused since the last compaction and
+	// tier2 clauses unused for the tier2 window.
+	cands := make([]z.C, 0, len(learnts)/2)
+	for _, p := range learnts {
+		switch c.tier(cDat.Chd(p)) {
+		case tierCore:
+			continue
+		case tier2:
+			if c.conflicts-c.used[p] <= c.tier2Window {
+				continue
+			}
+		default:
+			if c.used[p] > c.lastCompact {
+				continue
+			}
+		}
 		if cdb.InUse(p) {
 			continue
 		}
@@ -145,18 +280,33 @@ func (c *Cgc) Compact(cdb *Cdb) (int, int, int) {
 		if cdb.IsUnit(p) {
 			continue
 		}
-		// ok, add it to rm for removal below.
-		rms = append(rms, p)
-		learnts[n], learnts[top] = learnts[top], p
-		learnts = learnts[:top]
-		top--
+		cands = append(cands, p)
+	}
+	c.lastCompact = c.conflicts
+	g := gcLearnts{
+		learnts: cands,
+		cdat:    &cDat}
+	g.Sort()
+
+	lim := int(float64(len(cands)) * c.fraction)
+	rms := cands[len(cands)-lim:]
+	rmLitCount := 0
+	for _, p := range rms {
 		rmLitCount += cdb.Size(p)
-		lim--
-		if lim <= 0 {
-			break
+		delete(c.used, p)
+	}
+	cLocSlice(rms).Sort()
+	j := 0
+	for _, p := range learnts {
+		i := sort.Search(len(rms), func(i int) bool { return rms[i] >= p })
+		if i < len(rms) && rms[i] == p {
+			continue
 		}
+		learnts[j] = p
+		j++
 	}
-	cdb.Learnts = learnts
+	cdb.Learnts = learnts[:j]
+	cdb.traceRemove(rms)
 	c.rmq = append(c.rmq, rms...)
 	c.rmd += len(rms)
 	c.stRmd += int64(len(rms))
@@ -190,6 +340,22 @@ func (c *Cgc) CompactCDat(cdb *Cdb) (int, int) {
 }
 
 func (c *Cgc) relocate(cdb *Cdb, rlm map[z.C]z.C) {
+	if q, ok := rlm[cdb.Bot]; ok {
+		cdb.Bot = q
+	}
+	if cdb.Tracer != nil {
+		cdb.Tracer.Relocate(rlm)
+	}
+	used := make(map[z.C]int64, len(c.used))
+	for p, t := range c.used {
+		q, ok := rlm[p]
+		if !ok {
+			used[p] = t
+		} else if q != CNull {
+			used[q] = t
+		}
+	}
+	c.used = used
 	cdb.Learnts = relocateSlice(cdb.Learnts, rlm)
 	cdb.Added = relocateSlice(cdb.Added, rlm)
 	// reasons
@@ -66,3 +66,39 @@ func TestCgc(t *testing.T) {
 		}
 	}
 }
+
+func TestCgcTiers(t *testing.T) {
+	vars := NewVars(1025)
+	cdb := NewCdb(vars, 4096)
+	ms := make([]z.Lit, 3)
+	for i := 0; i < 4096; i++ {
+		for j := range ms {
+			ms[j] = z.Var(3*(i%300) + j + 1).Pos()
+		}
+		p := cdb.Learn(ms, i%10)
+		if i%2 == 0 {
+			cdb.Bump(p)
+		}
+	}
+	core, t2, local := cdb.gc.tierSizes(cdb)
+	if core+t2+local != len(cdb.Learnts) {
+		t.Fatalf("tier sizes %d %d %d of %d", core, t2, local, len(cdb.Learnts))
+	}
+	cdb.gc.fraction = 1.0
+	nU, _, _ := cdb.gc.Compact(cdb)
+	if nU == 0 {
+		t.Errorf("nothing removed")
+	}
+	c, t2Now, _ := cdb.gc.tierSizes(cdb)
+	if c != core {
+		t.Errorf("removed core clauses: %d != %d", c, core)
+	}
+	if t2Now != t2 {
+		t.Errorf("removed recently used tier2 clauses: %d != %d", t2Now, t2)
+	}
+	for _, p := range cdb.Learnts {
+		if cdb.gc.tier(cdb.Chd(p)) == tierLocal && cdb.gc.used[p] == 0 {
+			t.Errorf("unused local clause not removed")
+		}
+	}
+}
@@ -23,6 +23,9 @@ const (
 )
 
 func MakeChd(learnt bool, lbd, sz int) Chd {
+	if lbd > lbdMask>>szBits {
+		lbd = lbdMask >> szBits
+	}
 	v := uint32(0)
 	if learnt {
 		v |= lrnMask
@@ -40,6 +43,14 @@ func (c Chd) Lbd() uint32 {
 	return uint32((c & lbdMask) >> szBits)
 }
 
+// SetLbd returns c with lbd lbd.
+func (c Chd) SetLbd(lbd uint32) Chd {
+	if lbd > lbdMask>>szBits {
+		lbd = lbdMask >> szBits
+	}
+	return Chd((uint32(c) &^ lbdMask) | (lbd << szBits))
+}
+
 func (c Chd) Learnt() bool {
 	return c >= lrnMask
 }
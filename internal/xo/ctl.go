@@ -4,6 +4,7 @@
 package xo
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -21,6 +22,7 @@ type Ctl struct {
 	coLearn      chan []z.Lit
 	cStopOrPause chan bool
 	stFunc       func(stats *Stats) *Stats
+	ctx          context.Context // if not nil, stops the Solve when done
 }
 
 // NewCtl creates a new controller.
@@ -39,7 +41,13 @@ func NewCtl(s *S) *Ctl {
 // otherwise, if the solver was paused, then tick
 // blocks until unpause and returns true
 // otherwise it just returns true.
+//
+// If the solver was given a context by SolveContext which is done,
+// then Tick returns false.
 func (c *Ctl) Tick() bool {
+	if c.ctx != nil && c.ctx.Err() != nil {
+		return false
+	}
 	select {
 	case end, ok := <-c.cStopOrPause:
 		if end || !ok {
@@ -107,6 +115,19 @@ func (c *Ctl) Try(d time.Duration) int {
 	}
 }
 
+// SolveContext waits for the result until ctx is done, and then stops the
+// Solve, returning 0 by default if no result is available.
+func (c *Ctl) SolveContext(ctx context.Context) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return c.stop()
+	case res := <-c.cResult:
+		return res
+	}
+}
+
 // Type StatsResult encapsulates a pair of stats and a solve result.
 type StatsResult struct {
 	Result int
@@ -4,6 +4,7 @@
 package xo
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
@@ -58,6 +59,30 @@ func TestSolveTryEasy(t *testing.T) {
 	}
 }
 
+func TestSolveContext(t *testing.T) {
+	s := NewS()
+	gen.HardRand3Cnf(s, 1024)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r := s.GoSolve().SolveContext(ctx); r != 0 {
+		t.Errorf("solved hard problem too fast")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r := s.SolveContext(ctx); r != 0 {
+		t.Errorf("solved hard problem too fast")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("returned before deadline")
+	}
+
+	s = NewS()
+	gen.BinCycle(s, 4096)
+	if r := s.SolveContext(context.Background()); r != 1 {
+		t.Errorf("couldn't solve easy problem")
+	}
+}
+
 func TestSolvePauseUnpause(t *testing.T) {
 	s := NewS()
 	gen.HardRand3Cnf(s, 1024)
@@ -22,6 +22,12 @@ type Deriver struct {
 	Rdnt  []int8
 	Seen  []bool
 
+	// for proof hints
+	Used  []bool
+	ULits []z.Lit
+	XLits []z.Lit
+	Hints []z.C
+
 	Conflicts  int64
 	Learnt     int64
 	LearntLits int64
@@ -40,6 +46,7 @@ func NewDeriver(cdb *Cdb, g *Guess, t *Trail) *Deriver {
 		Lvls:       make([]bool, len(cdb.Vars.Levels)),
 		Rdnt:       make([]int8, len(cdb.Vars.Levels)),
 		Seen:       make([]bool, cdb.Vars.Top),
+		Used:       make([]bool, cdb.Vars.Top),
 		Conflicts:  0,
 		Learnt:     0,
 		LearntLits: 0,
@@ -56,7 +63,8 @@ func (d *Deriver) CopyWith(cdb *Cdb, g *Guess, t *Trail) *Deriver {
 		RLits: make([]z.Lit, len(d.RLits), cap(d.RLits)),
 		Lvls:  make([]bool, len(d.Lvls), cap(d.Lvls)),
 		Rdnt:  make([]int8, len(d.Rdnt), cap(d.Rdnt)),
-		Seen:  make([]bool, len(d.Seen), cap(d.Seen))}
+		Seen:  make([]bool, len(d.Seen), cap(d.Seen)),
+		Used:  make([]bool, len(d.Used), cap(d.Used))}
 	copy(other.CLits, d.CLits)
 	copy(other.RLits, d.RLits)
 	copy(other.Lvls, d.Lvls)
@@ -69,6 +77,7 @@ type Derived struct {
 	P           z.C
 	Unit        z.Lit
 	Size        int
+	Lbd         int
 	TargetLevel int
 }
 
@@ -102,6 +111,8 @@ func (d *Deriver) Derive(x z.C) *Derived {
 	var m z.Lit
 	var v z.Var
 	lbd := 0
+	hinted := cdb.traceHints
+	nUsed := 0
 
 	for i := d.Trail.Tail - 1; i >= 0; i-- {
 		if p != CNull {
@@ -120,8 +131,11 @@ func (d *Deriver) Derive(x z.C) *Derived {
 				Seen[v] = true
 
 				vLevel = aLevels[v]
-				// TBD: proof
 				if vLevel == 0 {
+					if hinted {
+						d.Used[v] = true
+						d.ULits = append(d.ULits, m)
+					}
 					continue
 				}
 				if vLevel != curLevel {
@@ -144,7 +158,9 @@ func (d *Deriver) Derive(x z.C) *Derived {
 		}
 		m = trail[i]
 		v = m.Var()
-		if !Seen[v] {
+		if !Seen[v] || aLevels[v] != curLevel {
+			// under chronological backtracking, literals at
+			// lower levels may follow those at curLevel.
 			continue
 		}
 		count--
@@ -156,6 +172,10 @@ func (d *Deriver) Derive(x z.C) *Derived {
 		p = reasons[v]
 		cdb.Bump(p)
 		p++
+		if hinted {
+			d.Used[v] = true
+			nUsed++
+		}
 	}
 	// cleanup seen
 	for _, m := range cLits {
@@ -175,9 +195,14 @@ func (d *Deriver) Derive(x z.C) *Derived {
 	d.minimize()
 
 	// add/construct result
-	result.P = cdb.Learn(d.CLits, lbd)
+	var hints []z.C
+	if hinted {
+		hints = d.hints(x, nUsed)
+	}
+	result.P = cdb.learn(d.CLits, lbd, hints)
 	result.Unit = cLits[0]
 	result.Size = len(cLits)
+	result.Lbd = lbd + 1 // the level of the uip
 
 	// and record some exciting stats
 	d.Learnt++
@@ -200,6 +225,9 @@ func (d *Deriver) minimize() int {
 	for ; i < len(cLits); i++ {
 		m := cLits[i]
 		if d.isRdnt(m) {
+			if d.Cdb.traceHints {
+				d.XLits = append(d.XLits, m)
+			}
 			continue
 		}
 		guess.Bump(m)
@@ -237,6 +265,72 @@ func (d *Deriver) minimize() int {
 	return res
 }
 
+// hints returns the hints for the learnt clause d.CLits derived from the
+// conflict x, as described in Tracer.  n is the number of variables at the
+// conflict level whose reasons were used in the derivation.
+func (d *Deriver) hints(x z.C, n int) []z.C {
+	cdb := d.Cdb
+	used := d.Used
+	seen := d.Seen
+	reasons := d.Vars.Reasons
+	levels := d.Vars.Levels
+	for _, m := range d.CLits {
+		seen[m.Var()] = true
+	}
+	// mark the variables whose reasons imply the literals removed by
+	// minimization.
+	stk := append(d.SLits[:0], d.XLits...)
+	for len(stk) > 0 {
+		m := stk[len(stk)-1]
+		stk = stk[:len(stk)-1]
+		v := m.Var()
+		if seen[v] || used[v] {
+			continue
+		}
+		used[v] = true
+		if levels[v] == 0 {
+			d.ULits = append(d.ULits, m)
+			continue
+		}
+		n++
+		db := cdb.CDat.D
+		for p := reasons[v] + 1; db[p] != z.LitNull; p++ {
+			stk = append(stk, db[p])
+		}
+	}
+	d.SLits = stk[:0]
+	d.XLits = d.XLits[:0]
+	for _, m := range d.CLits {
+		seen[m.Var()] = false
+	}
+
+	hs := d.Hints[:0]
+	for _, m := range d.ULits {
+		used[m.Var()] = false
+		seen[m.Var()] = false
+		hs = append(hs, cdb.rootUnit(m.Not()))
+	}
+	d.ULits = d.ULits[:0]
+	// reasons in trail order
+	j := len(hs)
+	trail := d.Trail.D
+	for i := d.Trail.Tail - 1; n > 0; i-- {
+		v := trail[i].Var()
+		if !used[v] {
+			continue
+		}
+		used[v] = false
+		n--
+		hs = append(hs, reasons[v])
+	}
+	for i, k := j, len(hs)-1; i < k; i, k = i+1, k-1 {
+		hs[i], hs[k] = hs[k], hs[i]
+	}
+	hs = append(hs, x)
+	d.Hints = hs
+	return hs
+}
+
 func (d *Deriver) isRdnt(m z.Lit) bool {
 	d.Rdnt[m.Var()] = 0
 	res := d.isRdntRec(m)
@@ -303,4 +397,8 @@ func (d *Deriver) growToVar(u z.Var) {
 	rdnt := make([]int8, N)
 	copy(rdnt, d.Rdnt)
 	d.Rdnt = rdnt
+
+	used := make([]bool, N)
+	copy(used, d.Used)
+	d.Used = used
 }
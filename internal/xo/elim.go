@@ -0,0 +1,711 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/z"
+)
+
+const (
+	elimOccs     = 16       // default Options.ElimOccs
+	elimMaxSize  = 20       // maximum size of a resolvent
+	elimMaxSteps = 20000000 // literal visits per elimination pass
+	elimRounds   = 8        // passes per elimination, while units are found
+)
+
+// Type elim holds the variables removed from the problem by bounded
+// variable elimination, together with the clauses needed to extend
+// models to them and to restore them.
+type elim struct {
+	frozen []uint32    // freeze counts, frozen variables are not eliminated
+	elimd  []bool      // eliminated variables
+	vals   []int8      // values of eliminated variables in the last model
+	stack  []elimBlock // in order of elimination
+	dirty  bool        // whether clauses were added since the last elimination
+
+	stVars         int64
+	stSubsumed     int64
+	stStrengthened int64
+	stSubstituted  int64
+}
+
+// Type elimBlock records the clauses removed when the variable v is
+// eliminated.  Each clause in ms is terminated by z.LitNull.
+type elimBlock struct {
+	v  z.Var
+	ms []z.Lit
+}
+
+func newElim(capHint int) *elim {
+	return &elim{
+		frozen: make([]uint32, capHint),
+		elimd:  make([]bool, capHint),
+		vals:   make([]int8, capHint)}
+}
+
+func (e *elim) Copy() *elim {
+	other := &elim{
+		frozen: make([]uint32, len(e.frozen), cap(e.frozen)),
+		elimd:  make([]bool, len(e.elimd), cap(e.elimd)),
+		vals:   make([]int8, len(e.vals), cap(e.vals)),
+		stack:  make([]elimBlock, len(e.stack)),
+		dirty:  e.dirty}
+	copy(other.frozen, e.frozen)
+	copy(other.elimd, e.elimd)
+	copy(other.vals, e.vals)
+	for i, b := range e.stack {
+		other.stack[i] = elimBlock{v: b.v, ms: append([]z.Lit(nil), b.ms...)}
+	}
+	return other
+}
+
+func (e *elim) growToVar(u z.Var) {
+	w := u + 1
+	f := make([]uint32, w)
+	copy(f, e.frozen)
+	e.frozen = f
+	d := make([]bool, w)
+	copy(d, e.elimd)
+	e.elimd = d
+	v := make([]int8, w)
+	copy(v, e.vals)
+	e.vals = v
+}
+
+func (e *elim) readStats(st *Stats) {
+	st.Eliminated += e.stVars
+	e.stVars = 0
+	st.Subsumed += e.stSubsumed
+	e.stSubsumed = 0
+	st.Strengthened += e.stStrengthened
+	e.stStrengthened = 0
+	st.Substituted += e.stSubstituted
+	e.stSubstituted = 0
+}
+
+// value returns the value of m under the values vals of the variables
+// which are not eliminated.
+func (e *elim) value(m z.Lit, vals []int8) int8 {
+	v := m.Var()
+	if !e.elimd[v] {
+		return vals[m]
+	}
+	if m.IsPos() {
+		return e.vals[v]
+	}
+	return -e.vals[v]
+}
+
+// extend extends the model vals of the remaining clauses to the
+// eliminated variables.
+//
+// Eliminated variables are considered in the reverse order of
+// elimination.  Each is false unless a clause containing it positively
+// is otherwise false, which by construction implies that no clause
+// containing it negatively is otherwise false.
+func (e *elim) extend(vals []int8) {
+	for i := len(e.stack) - 1; i >= 0; i-- {
+		b := &e.stack[i]
+		m := b.v.Pos()
+		val := int8(-1)
+		ms := b.ms
+		for len(ms) > 0 && val == -1 {
+			has, sat := false, false
+			k := 0
+			for ; ms[k] != z.LitNull; k++ {
+				if ms[k] == m {
+					has = true
+					continue
+				}
+				if e.value(ms[k], vals) == 1 {
+					sat = true
+				}
+			}
+			ms = ms[k+1:]
+			if has && !sat {
+				val = 1
+			}
+		}
+		e.vals[b.v] = val
+	}
+}
+
+// Freeze prevents the variable v from being eliminated, restoring it if
+// it is eliminated, until a corresponding call to Melt.  Calls to Freeze
+// and Melt nest.
+//
+// Eliminated variables are restored when they subsequently occur in added
+// clauses or assumptions, and all of them are restored when a Test scope is
+// opened, since they cannot be restored under it.  Restoring is costly, so
+// incremental users should freeze such variables beforehand.
+func (s *S) Freeze(v z.Var) {
+	s.ensureLitCap(v.Pos())
+	e := s.elim
+	if e.elimd[v] {
+		s.ensure0()
+		s.restore(v)
+	}
+	e.frozen[v]++
+}
+
+// Melt undoes a call to Freeze.
+func (s *S) Melt(v z.Var) {
+	e := s.elim
+	if int(v) < len(e.frozen) && e.frozen[v] > 0 {
+		e.frozen[v]--
+	}
+}
+
+// simplifyReady returns whether the added clauses should be simplified,
+// which requires that some have been added since the last simplification,
+// decision level 0 outside of any Test scope and no activations.
+func (s *S) simplifyReady() bool {
+	o := s.opts
+	if !(o.Eliminate || o.Probe || o.Substitute) || !s.elim.dirty {
+		return false
+	}
+	if s.Trail.Level != 0 || len(s.testLevels) != 0 {
+		return false
+	}
+	return s.Active == nil && !s.Cdb.traceHints
+}
+
+// simplify simplifies the added clauses by equivalent literal
+// substitution, failed literal probing and elimination, according to the
+// options of s.
+//
+// simplify returns CNull, or a conflict at level 0 if a derived unit leads
+// to one.
+func (s *S) simplify() z.C {
+	s.elim.dirty = false
+	if s.opts.Substitute {
+		if x := s.substitute(); x != CNull {
+			return x
+		}
+	}
+	if s.opts.Probe {
+		if x := s.probe(); x != CNull {
+			return x
+		}
+	}
+	if s.opts.Eliminate {
+		return s.eliminate()
+	}
+	return CNull
+}
+
+// eliminate simplifies the added clauses by subsumption, self subsuming
+// resolution and bounded variable elimination.  The variables of the
+// pending assumptions are not eliminated.
+//
+// eliminate returns CNull, or a conflict at level 0 if a derived unit
+// leads to one.
+func (s *S) eliminate() z.C {
+	for i := 0; i < elimRounds; i++ {
+		p := s.newElimPass()
+		p.run()
+		if x := p.commit(); x != CNull {
+			return x
+		}
+		if !p.unit {
+			return CNull
+		}
+		if x := s.Trail.Prop(); x != CNull {
+			return x
+		}
+	}
+	return CNull
+}
+
+// restore adds back the clauses removed when v was eliminated, restoring
+// the variables eliminated after v which occur in them.  restore must be
+// called at decision level 0.
+func (s *S) restore(v z.Var) {
+	if s.Trail.Level != 0 {
+		panic("eliminated variable used under test scope")
+	}
+	e := s.elim
+	i := len(e.stack) - 1
+	for e.stack[i].v != v {
+		i--
+	}
+	ms := e.stack[i].ms
+	e.stack = append(e.stack[:i], e.stack[i+1:]...)
+	e.elimd[v] = false
+	e.dirty = true
+	s.Guess.restore(v)
+	for len(ms) > 0 {
+		k := 0
+		for ; ms[k] != z.LitNull; k++ {
+			if e.elimd[ms[k].Var()] {
+				s.restore(ms[k].Var())
+			}
+		}
+		for _, m := range ms[:k] {
+			s.Cdb.Add(m)
+		}
+		loc, u := s.Cdb.Add(z.LitNull)
+		if u != z.LitNull {
+			s.Trail.Assign(u, loc)
+		}
+		ms = ms[k+1:]
+	}
+}
+
+// restoreAll restores all eliminated variables.  restoreAll must be
+// called at decision level 0.
+func (s *S) restoreAll() {
+	e := s.elim
+	for len(e.stack) > 0 {
+		s.restore(e.stack[len(e.stack)-1].v)
+	}
+}
+
+// restoreLits restores the eliminated variables of the literals ms, which
+// may be the pending literals of s.Cdb.
+func (s *S) restoreLits(ms []z.Lit) {
+	e := s.elim
+	if len(e.stack) == 0 {
+		return
+	}
+	restored := false
+	for i := range ms {
+		if !e.elimd[ms[i].Var()] {
+			continue
+		}
+		if !restored {
+			// restoring uses the pending literals of s.Cdb.
+			ms = append([]z.Lit(nil), ms...)
+			s.Cdb.AddLits = s.Cdb.AddLits[:0]
+			restored = true
+		}
+		s.restore(ms[i].Var())
+	}
+	if restored {
+		s.Cdb.AddLits = append(s.Cdb.AddLits, ms...)
+	}
+}
+
+// Type elimCls is a clause in an elimination pass.
+type elimCls struct {
+	p      z.C     // location, or CNull if derived in the pass
+	ms     []z.Lit // the literals which are not false at level 0
+	sig    uint64  // set of variables mod 64
+	dead   bool
+	queued bool
+}
+
+// Type elimPass holds the occurrence lists of the added clauses for one
+// pass of elimination.
+type elimPass struct {
+	s       *S
+	cls     []elimCls
+	occs    [][]int // clause indices by literal
+	queue   []int   // clauses to check for subsumption
+	marks   []int8  // by variable
+	assumed []bool  // by variable
+	res     []z.Lit
+	cands   []int
+	steps   int64
+	unit    bool // true if a derived clause is unit
+
+	rms   []z.C     // added clauses to remove
+	drops [][]z.Lit // derived clauses to remove
+	keeps []z.C     // added clauses to remove which stay in the proof
+}
+
+func (s *S) newElimPass() *elimPass {
+	top := int(s.Vars.Top)
+	e := &elimPass{
+		s:       s,
+		occs:    make([][]int, 2*top),
+		marks:   make([]int8, top),
+		assumed: s.assumedVars()}
+	cdb := s.Cdb
+	vals := s.Vars.Vals
+	var ms []z.Lit
+	for _, p := range cdb.Added {
+		ms = cdb.Lits(p, ms[:0])
+		sat := false
+		j := 0
+		for _, m := range ms {
+			switch vals[m] {
+			case 1:
+				sat = true
+			case 0:
+				ms[j] = m
+				j++
+			}
+		}
+		if sat {
+			if !cdb.InUse(p) {
+				e.rms = append(e.rms, p)
+			}
+			continue
+		}
+		e.add(p, append([]z.Lit(nil), ms[:j]...))
+	}
+	return e
+}
+
+// assumedVars returns which variables are pending assumptions.
+func (s *S) assumedVars() []bool {
+	assumed := make([]bool, s.Vars.Top)
+	for _, m := range s.assumes {
+		assumed[m.Var()] = true
+	}
+	return assumed
+}
+
+func elimSig(ms []z.Lit) uint64 {
+	sig := uint64(0)
+	for _, m := range ms {
+		sig |= 1 << (uint(m.Var()) & 63)
+	}
+	return sig
+}
+
+// add adds a clause with literals ms at location p to the pass.
+func (e *elimPass) add(p z.C, ms []z.Lit) int {
+	i := len(e.cls)
+	e.cls = append(e.cls, elimCls{p: p, ms: ms, sig: elimSig(ms)})
+	for _, m := range ms {
+		e.occs[m] = append(e.occs[m], i)
+	}
+	e.enqueue(i)
+	return i
+}
+
+// derive adds the derived clause ms to the pass.
+func (e *elimPass) derive(ms []z.Lit) {
+	if t := e.s.Cdb.Tracer; t != nil {
+		t.Add(CNull, ms, nil)
+	}
+	if len(ms) <= 1 {
+		e.unit = true
+	}
+	e.add(CNull, ms)
+}
+
+// kill removes the clause i.
+func (e *elimPass) kill(i int) {
+	c := &e.cls[i]
+	c.dead = true
+	if c.p != CNull {
+		e.rms = append(e.rms, c.p)
+		return
+	}
+	e.drops = append(e.drops, c.ms)
+}
+
+// hide removes the clause i containing an eliminated variable.  The clause
+// stays in the proof, if any, since restoring the variable adds it back
+// without tracing it.
+func (e *elimPass) hide(i int) {
+	c := &e.cls[i]
+	c.dead = true
+	if c.p != CNull {
+		e.keeps = append(e.keeps, c.p)
+	}
+}
+
+func (e *elimPass) enqueue(i int) {
+	if e.cls[i].queued {
+		return
+	}
+	e.cls[i].queued = true
+	e.queue = append(e.queue, i)
+}
+
+// live returns the clauses containing m which are not removed.
+func (e *elimPass) live(m z.Lit) []int {
+	is := e.occs[m]
+	j := 0
+	for _, i := range is {
+		if e.cls[i].dead {
+			continue
+		}
+		is[j] = i
+		j++
+	}
+	e.occs[m] = is[:j]
+	return is[:j]
+}
+
+func (e *elimPass) done() bool {
+	return e.unit || e.steps > elimMaxSteps
+}
+
+// run subsumes and strengthens the clauses of the pass and then
+// eliminates variables, cheapest first, until a unit is derived or the
+// step budget is exhausted.
+func (e *elimPass) run() {
+	e.subsume()
+	s := e.s
+	vals := s.Vars.Vals
+	costs := make([]int, s.Vars.Max+1)
+	var vs []z.Var
+	for v := z.Var(1); v <= s.Vars.Max; v++ {
+		if !e.eliminable(v) {
+			continue
+		}
+		np, nn := len(e.live(v.Pos())), len(e.live(v.Neg()))
+		if np+nn == 0 || np+nn > int(s.opts.ElimOccs) {
+			continue
+		}
+		costs[v] = np * nn
+		vs = append(vs, v)
+	}
+	sort.SliceStable(vs, func(i, j int) bool {
+		return costs[vs[i]] < costs[vs[j]]
+	})
+	for _, v := range vs {
+		if e.done() {
+			return
+		}
+		if vals[v.Pos()] != 0 {
+			continue
+		}
+		if e.eliminate(v) {
+			e.subsume()
+		}
+	}
+}
+
+func (e *elimPass) eliminable(v z.Var) bool {
+	el := e.s.elim
+	if el.elimd[v] || el.frozen[v] != 0 || e.assumed[v] {
+		return false
+	}
+	return e.s.Vars.Vals[v.Pos()] == 0
+}
+
+// subsume removes the clauses subsumed by queued clauses and strengthens
+// those which can be by self subsuming resolution with them.
+func (e *elimPass) subsume() {
+	for len(e.queue) > 0 && !e.done() {
+		i := e.queue[len(e.queue)-1]
+		e.queue = e.queue[:len(e.queue)-1]
+		e.cls[i].queued = false
+		if e.cls[i].dead {
+			continue
+		}
+		e.backward(i)
+	}
+}
+
+// backward removes or strengthens the clauses which contain all the
+// literals of the clause i, with at most one of them negated.
+func (e *elimPass) backward(i int) {
+	cms, sig := e.cls[i].ms, e.cls[i].sig
+	best := cms[0]
+	for _, m := range cms[1:] {
+		if len(e.occs[m])+len(e.occs[m.Not()]) < len(e.occs[best])+len(e.occs[best.Not()]) {
+			best = m
+		}
+	}
+	ds := append(e.cands[:0], e.live(best)...)
+	ds = append(ds, e.live(best.Not())...)
+	e.cands = ds
+	marks := e.marks
+	for _, m := range cms {
+		marks[m.Var()] = m.Sign()
+	}
+	n := len(cms)
+	for _, j := range ds {
+		d := &e.cls[j]
+		if j == i || d.dead || len(d.ms) < n || sig&^d.sig != 0 {
+			continue
+		}
+		e.steps += int64(len(d.ms))
+		k := 0
+		flip := z.LitNull
+		for _, m := range d.ms {
+			mk := marks[m.Var()]
+			if mk == 0 {
+				continue
+			}
+			if mk != m.Sign() {
+				if flip != z.LitNull {
+					k = -1
+					break
+				}
+				flip = m
+			}
+			k++
+		}
+		if k != n {
+			continue
+		}
+		if flip == z.LitNull {
+			e.s.elim.stSubsumed++
+			e.kill(j)
+			continue
+		}
+		e.strengthen(j, flip)
+	}
+	for _, m := range cms {
+		marks[m.Var()] = 0
+	}
+}
+
+// strengthen removes the literal m from the clause j.
+func (e *elimPass) strengthen(j int, m z.Lit) {
+	e.s.elim.stStrengthened++
+	d := e.cls[j]
+	ms := make([]z.Lit, 0, len(d.ms)-1)
+	for _, n := range d.ms {
+		if n != m {
+			ms = append(ms, n)
+		}
+	}
+	e.kill(j)
+	e.derive(ms)
+}
+
+// resolve returns the resolvent on v of the clauses i, containing v, and
+// j, containing its negation, and whether it is not a tautology.
+func (e *elimPass) resolve(i, j int, v z.Var) ([]z.Lit, bool) {
+	marks := e.marks
+	res := e.res[:0]
+	for _, m := range e.cls[i].ms {
+		if m.Var() == v {
+			continue
+		}
+		marks[m.Var()] = m.Sign()
+		res = append(res, m)
+	}
+	ok := true
+	for _, m := range e.cls[j].ms {
+		mv := m.Var()
+		if mv == v || marks[mv] == m.Sign() {
+			continue
+		}
+		if marks[mv] != 0 {
+			ok = false
+			break
+		}
+		res = append(res, m)
+	}
+	e.steps += int64(len(res))
+	for _, m := range e.cls[i].ms {
+		marks[m.Var()] = 0
+	}
+	e.res = res
+	return res, ok
+}
+
+// eliminate eliminates v if the number of non tautological resolvents of
+// the clauses containing v does not exceed the number of those clauses.
+func (e *elimPass) eliminate(v z.Var) bool {
+	pos, neg := e.live(v.Pos()), e.live(v.Neg())
+	lim := len(pos) + len(neg)
+	if lim == 0 || lim > int(e.s.opts.ElimOccs) {
+		return false
+	}
+	n := 0
+	for _, i := range pos {
+		for _, j := range neg {
+			rs, ok := e.resolve(i, j, v)
+			if !ok {
+				continue
+			}
+			n++
+			if n > lim || len(rs) > elimMaxSize {
+				return false
+			}
+		}
+	}
+	var rss [][]z.Lit
+	for _, i := range pos {
+		for _, j := range neg {
+			if rs, ok := e.resolve(i, j, v); ok {
+				rss = append(rss, append([]z.Lit(nil), rs...))
+			}
+		}
+	}
+	el := e.s.elim
+	b := elimBlock{v: v}
+	for _, is := range [...][]int{pos, neg} {
+		for _, i := range is {
+			b.ms = append(b.ms, e.cls[i].ms...)
+			b.ms = append(b.ms, z.LitNull)
+		}
+	}
+	for _, is := range [...][]int{pos, neg} {
+		for _, i := range is {
+			e.hide(i)
+		}
+	}
+	for _, rs := range rss {
+		e.derive(rs)
+	}
+	el.stack = append(el.stack, b)
+	el.elimd[v] = true
+	el.stVars++
+	e.s.Guess.eliminate(v)
+	return true
+}
+
+// commit applies the pass to s.Cdb, removing the learnt clauses which
+// contain eliminated variables, and assigns the derived units.  commit
+// returns a clause which is false at level 0, if any.
+func (e *elimPass) commit() z.C {
+	s := e.s
+	cdb := s.Cdb
+	elimd := s.elim.elimd
+	rms := e.rms
+	var ms []z.Lit
+	for _, p := range cdb.Learnts {
+		if cdb.InUse(p) {
+			continue
+		}
+		ms = cdb.Lits(p, ms[:0])
+		for _, m := range ms {
+			if elimd[m.Var()] {
+				rms = append(rms, p)
+				break
+			}
+		}
+	}
+	if t := cdb.Tracer; t != nil {
+		for _, ms := range e.drops {
+			t.Remove(CNull, ms)
+		}
+	}
+	cdb.traceRemove(rms)
+	rms = append(rms, e.keeps...)
+	if len(rms) > 0 {
+		cdb.Added = removeLocs(cdb.Added, rms)
+		cdb.Learnts = removeLocs(cdb.Learnts, rms)
+		cdb.gc.remove(cdb, rms...)
+	}
+	x := CNull
+	vals := s.Vars.Vals
+	for i := range e.cls {
+		c := &e.cls[i]
+		if c.dead || c.p != CNull {
+			continue
+		}
+		p := cdb.CDat.AddLits(MakeChd(false, 0, len(c.ms)), c.ms)
+		cdb.Added = append(cdb.Added, p)
+		switch len(c.ms) {
+		case 0:
+			cdb.Bot = p
+			x = p
+		case 1:
+			switch vals[c.ms[0]] {
+			case 0:
+				s.Trail.Assign(c.ms[0], p)
+			case -1:
+				x = p
+			}
+		default:
+			cdb.link(p, c.ms)
+		}
+	}
+	return x
+}
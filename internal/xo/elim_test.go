@@ -0,0 +1,248 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-air/gini/z"
+)
+
+// elimCnf returns a random cnf with n variables containing a chain of
+// and gates, whose inner variables are eliminable, and m random 3
+// literal clauses.
+func elimCnf(rng *rand.Rand, n, m int) [][]z.Lit {
+	lit := func() z.Lit {
+		v := z.Var(rng.Intn(n) + 1)
+		if rng.Intn(2) == 0 {
+			return v.Neg()
+		}
+		return v.Pos()
+	}
+	var cnf [][]z.Lit
+	for i := 0; i < n/4; i++ {
+		g := z.Var(n + i + 1).Pos()
+		a, b := lit(), lit()
+		if a.Var() == b.Var() {
+			continue
+		}
+		cnf = append(cnf, []z.Lit{g.Not(), a}, []z.Lit{g.Not(), b},
+			[]z.Lit{g, a.Not(), b.Not()}, []z.Lit{g, lit(), lit()})
+	}
+	for i := 0; i < m; i++ {
+		a, b, c := lit(), lit(), lit()
+		if a.Var() == b.Var() || a.Var() == c.Var() || b.Var() == c.Var() {
+			continue
+		}
+		cnf = append(cnf, []z.Lit{a, b, c})
+	}
+	return cnf
+}
+
+func addCnf(s *S, cnf [][]z.Lit) {
+	for _, c := range cnf {
+		for _, m := range c {
+			s.Add(m)
+		}
+		s.Add(z.LitNull)
+	}
+}
+
+func checkCnf(t *testing.T, s *S, cnf [][]z.Lit) {
+	for _, c := range cnf {
+		sat := false
+		for _, m := range c {
+			if s.Value(m) {
+				sat = true
+				break
+			}
+		}
+		if !sat {
+			t.Fatalf("model does not satisfy %v", c)
+		}
+	}
+}
+
+func TestElim(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	st := NewStats()
+	sats := 0
+	for i := 0; i < 40; i++ {
+		cnf := elimCnf(rng, 60, 200+rng.Intn(80))
+		s := NewS()
+		s.SetOptions(&Options{Eliminate: true})
+		addCnf(s, cnf)
+		o := NewS()
+		o.SetOptions(&Options{Eliminate: false})
+		addCnf(o, cnf)
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Fatalf("eliminated result %d", r)
+		}
+		if r == 1 {
+			sats++
+			checkCnf(t, s, cnf)
+		}
+		s.ReadStats(st)
+	}
+	if st.Eliminated == 0 {
+		t.Errorf("nothing eliminated")
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+}
+
+func TestElimIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 60, 150)
+		s := NewS()
+		s.SetOptions(&Options{Eliminate: true})
+		frozen := z.Var(rng.Intn(60) + 1)
+		s.Freeze(frozen)
+		addCnf(s, cnf)
+		if s.Solve() == 1 {
+			checkCnf(t, s, cnf)
+		}
+		if s.elim.elimd[frozen] {
+			t.Fatalf("eliminated frozen variable")
+		}
+		s.Melt(frozen)
+		for j := 0; j < 4; j++ {
+			// new clauses and assumptions over possibly eliminated
+			// variables.
+			more := elimCnf(rng, 75, 20)
+			addCnf(s, more)
+			cnf = append(cnf, more...)
+			a := z.Var(rng.Intn(75) + 1).Pos()
+			s.Assume(a)
+			o := NewS()
+			addCnf(o, cnf)
+			o.Assume(a)
+			r := s.Solve()
+			if r != o.Solve() {
+				t.Fatalf("incremental eliminated result %d", r)
+			}
+			if r == 1 {
+				checkCnf(t, s, cnf)
+				if !s.Value(a) {
+					t.Fatalf("assumption false")
+				}
+			}
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	st := NewStats()
+	sats := 0
+	for i := 0; i < 40; i++ {
+		cnf := elimCnf(rng, 60, 120)
+		// equivalences between random literals
+		for j := 0; j < 8; j++ {
+			a := z.Var(rng.Intn(60) + 1).Pos()
+			b := z.Var(rng.Intn(60) + 1).Pos()
+			if rng.Intn(2) == 0 {
+				b = b.Not()
+			}
+			if a.Var() == b.Var() {
+				continue
+			}
+			cnf = append(cnf, []z.Lit{a.Not(), b}, []z.Lit{a, b.Not()})
+		}
+		s := NewS()
+		s.SetOptions(&Options{Substitute: true, Probe: true})
+		addCnf(s, cnf)
+		o := NewS()
+		addCnf(o, cnf)
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Fatalf("substituted result %d", r)
+		}
+		if r == 1 {
+			sats++
+			checkCnf(t, s, cnf)
+		}
+		s.ReadStats(st)
+	}
+	if st.Substituted == 0 {
+		t.Errorf("nothing substituted")
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	s := NewS()
+	s.SetOptions(&Options{Probe: true})
+	a, b, c := z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos()
+	addCnf(s, [][]z.Lit{{a.Not(), b}, {a.Not(), c}, {b.Not(), c.Not()}})
+	if s.Solve() != 1 {
+		t.Fatalf("not sat")
+	}
+	st := NewStats()
+	s.ReadStats(st)
+	if st.FailedLits != 1 {
+		t.Errorf("failed literals: %d", st.FailedLits)
+	}
+	if s.Vars.Levels[a.Var()] != 0 || s.Value(a) {
+		t.Errorf("failed literal not false at level 0")
+	}
+}
+
+func TestElimTest(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	tests := 0
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 60, 150)
+		s := NewS()
+		s.SetOptions(&Options{Eliminate: true})
+		addCnf(s, cnf)
+		if s.Solve() != 1 {
+			continue
+		}
+		var elimd []z.Var
+		for v := z.Var(1); v <= s.Vars.Max; v++ {
+			if s.elim.elimd[v] {
+				elimd = append(elimd, v)
+			}
+		}
+		if len(elimd) < 2 {
+			continue
+		}
+		tests++
+		// eliminated variables assumed by Test and under its scope.
+		a, b := elimd[0].Pos(), elimd[1].Pos()
+		s.Assume(a)
+		if r, _ := s.Test(nil); r == -1 {
+			s.Untest()
+			continue
+		}
+		s.Assume(b)
+		o := NewS()
+		addCnf(o, cnf)
+		o.Assume(a, b)
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Fatalf("eliminated result under test %d", r)
+		}
+		if r == 1 {
+			checkCnf(t, s, cnf)
+			if !s.Value(a) || !s.Value(b) {
+				t.Fatalf("assumption false")
+			}
+		}
+		if s.Untest() != 0 || s.Solve() != 1 {
+			t.Fatalf("untest")
+		}
+		checkCnf(t, s, cnf)
+	}
+	if tests == 0 {
+		t.Errorf("nothing eliminated")
+	}
+}
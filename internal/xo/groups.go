@@ -0,0 +1,229 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/z"
+)
+
+// groups tags clauses with user supplied group ids, each group having an
+// activation literal which is assumed whenever the solver makes
+// assumptions at level 0.
+//
+// Grouped clauses are pending until the solver next makes assumptions at
+// level 0, when groups without activation literals are given them.  This
+// way, activation variables are allocated above the variables used so
+// far, even if the caller does not allocate variables with Lit.
+type groups struct {
+	acts    map[int]z.Lit // activation literal by id
+	ids     map[z.Var]int // id by activation variable
+	open    bool
+	id      int     // id of the open group
+	pending []z.Lit // pending clauses, each terminated by z.LitNull
+	pids    []int   // group id by pending clause
+	ms      []z.Lit
+}
+
+func newGroups() *groups {
+	return &groups{
+		acts: make(map[int]z.Lit),
+		ids:  make(map[z.Var]int)}
+}
+
+func (g *groups) Copy() *groups {
+	if g == nil {
+		return nil
+	}
+	other := newGroups()
+	for id, m := range g.acts {
+		other.acts[id] = m
+	}
+	for v, id := range g.ids {
+		other.ids[v] = id
+	}
+	other.open = g.open
+	other.id = g.id
+	other.pending = append([]z.Lit(nil), g.pending...)
+	other.pids = append([]int(nil), g.pids...)
+	return other
+}
+
+// AddGroup causes subsequently added clauses to belong to the group
+// identified by id, until EndGroup or the next AddGroup.  Clauses may be
+// added to a group in several pieces.
+//
+// Clauses belonging to a group are removed with RemoveGroup, and after
+// unsat, GroupWhy gives the groups whose clauses were used.  Only clauses
+// added with Add are grouped; other constraints are not.
+//
+// Groups are implemented with activation literals, allocated above
+// MaxVar when the solver next solves or tests, so variables introduced
+// after that should be allocated with Lit.  Like Activate, AddGroup
+// disables variable elimination.
+func (s *S) AddGroup(id int) {
+	s.ensure0()
+	s.ensureActive()
+	if s.groups == nil {
+		s.groups = newGroups()
+	}
+	s.groups.open = true
+	s.groups.id = id
+}
+
+// EndGroup ends the group opened by the last AddGroup, so that
+// subsequently added clauses belong to no group.
+func (s *S) EndGroup() {
+	if s.groups != nil {
+		s.groups.open = false
+	}
+}
+
+// RemoveGroup removes the clauses of the group identified by id, including
+// learnt clauses which depend on them.  The id may be reused by AddGroup
+// afterwards.
+func (s *S) RemoveGroup(id int) {
+	s.ensure0()
+	g := s.groups
+	if g == nil {
+		return
+	}
+	if g.open && g.id == id {
+		g.open = false
+	}
+	g.removePending(id)
+	act, ok := g.acts[id]
+	if !ok {
+		return
+	}
+	delete(g.acts, id)
+	delete(g.ids, act.Var())
+	if s.Vars.Vals[act] != -1 {
+		s.Active.Deactivate(s.Cdb, act)
+		return
+	}
+	// the group is inconsistent by itself and its activation literal is
+	// false at level 0, so it cannot be recycled.
+	a := s.Active
+	v := act.Var()
+	occs := a.Occs[v]
+	a.Occs[v] = nil
+	a.IsActive[v] = false
+	s.Cdb.Remove(occs...)
+}
+
+func (g *groups) removePending(id int) {
+	i, j, k := 0, 0, 0
+	for _, pid := range g.pids {
+		e := i
+		for g.pending[e] != z.LitNull {
+			e++
+		}
+		e++
+		if pid != id {
+			g.pids[k] = pid
+			k++
+			j += copy(g.pending[j:], g.pending[i:e])
+		}
+		i = e
+	}
+	g.pids = g.pids[:k]
+	g.pending = g.pending[:j]
+}
+
+// GroupWhy appends to dst the ids of groups whose clauses together caused
+// the previous call to be unsat, in increasing order.  If the previous
+// call was not unsat, or was unsat regardless of groups, GroupWhy returns
+// dst.
+func (s *S) GroupWhy(dst []int) []int {
+	g := s.groups
+	if g == nil {
+		return dst
+	}
+	start := len(dst)
+	g.ms = s.why(g.ms[:0])
+	for _, m := range g.ms {
+		if id, ok := g.ids[m.Var()]; ok {
+			dst = append(dst, id)
+		}
+	}
+	sort.Ints(dst[start:])
+	return dst
+}
+
+// addGroupClause makes the clause in s.Cdb.AddLits a pending clause of the
+// open group.
+func (s *S) addGroupClause() {
+	g := s.groups
+	g.pending = append(g.pending, s.Cdb.AddLits...)
+	g.pending = append(g.pending, z.LitNull)
+	g.pids = append(g.pids, g.id)
+	s.Cdb.AddLits = s.Cdb.AddLits[:0]
+}
+
+// addPending adds the pending clauses of groups at level 0.  Unlike
+// ActivateWith, it accepts clauses which are empty or true at level 0.
+func (s *S) addPending() {
+	g := s.groups
+	if len(g.pids) == 0 {
+		return
+	}
+	vars := s.Vars
+	for _, m := range g.pending {
+		if m.Var() > vars.Max {
+			vars.Max = m.Var()
+		}
+	}
+	i := 0
+	for _, id := range g.pids {
+		act, ok := g.acts[id]
+		if !ok {
+			act = s.Active.Lit(s)
+			if act.Var() > vars.Max {
+				vars.Max = act.Var()
+			}
+			s.Active.IsActive[act.Var()] = true
+			g.acts[id] = act
+			g.ids[act.Var()] = id
+		}
+		for ; g.pending[i] != z.LitNull; i++ {
+			s.Cdb.Add(g.pending[i])
+		}
+		i++
+		s.Cdb.Add(act.Not())
+		s.Cdb.checkModel = true
+		loc, u := s.Cdb.Add(0)
+		if loc == CInf {
+			continue
+		}
+		if u != z.LitNull {
+			// the group is inconsistent by itself.
+			s.Trail.Assign(u, loc)
+		}
+		g.ms = s.Cdb.Lits(loc, g.ms[:0])
+		s.Cdb.addOccs(loc, g.ms)
+	}
+	g.pending = g.pending[:0]
+	g.pids = g.pids[:0]
+}
+
+// groupAssumes adds the pending clauses of groups and prepends the
+// activation literals of the groups to the assumptions.
+func (s *S) groupAssumes() {
+	g := s.groups
+	if g == nil {
+		return
+	}
+	s.addPending()
+	if len(g.acts) == 0 {
+		return
+	}
+	ms := make([]z.Lit, 0, len(g.acts)+len(s.assumes))
+	for _, m := range g.acts {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+	s.assumes = append(ms, s.assumes...)
+}
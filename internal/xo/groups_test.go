@@ -0,0 +1,143 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/go-air/gini/z"
+)
+
+func TestGroups(t *testing.T) {
+	a, b, c := z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos()
+	s := NewS()
+	s.AddGroup(1)
+	s.Add(a)
+	s.Add(0)
+	s.AddGroup(2)
+	s.Add(a.Not())
+	s.Add(b)
+	s.Add(0)
+	s.AddGroup(3)
+	s.Add(b.Not())
+	s.Add(0)
+	s.AddGroup(4)
+	s.Add(c)
+	s.Add(0)
+	s.EndGroup()
+	s.Add(a)
+	s.Add(c)
+	s.Add(0)
+	if s.Solve() != -1 {
+		t.Fatalf("groups sat")
+	}
+	if ids := s.GroupWhy(nil); !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Errorf("core %v", ids)
+	}
+	if ms := s.Why(nil); len(ms) != 0 {
+		t.Errorf("why gave group literals %v", ms)
+	}
+
+	// failed assumptions and groups together.
+	o := s.Copy()
+	o.RemoveGroup(2)
+	o.Assume(c.Not())
+	if o.Solve() != -1 {
+		t.Fatalf("sat with c false")
+	}
+	if ids := o.GroupWhy(nil); !reflect.DeepEqual(ids, []int{4}) {
+		t.Errorf("core %v", ids)
+	}
+	if ms := o.Why(nil); !reflect.DeepEqual(ms, []z.Lit{c.Not()}) {
+		t.Errorf("why %v", ms)
+	}
+	if o.Solve() != 1 {
+		t.Fatalf("unsat without group 2")
+	}
+	if !o.Value(a) || o.Value(b) || !o.Value(c) {
+		t.Errorf("wrong model")
+	}
+
+	// a group which is inconsistent by itself.
+	s.RemoveGroup(1)
+	s.AddGroup(5)
+	s.Add(c.Not())
+	s.Add(0)
+	s.EndGroup()
+	if s.Solve() != -1 {
+		t.Fatalf("sat with group 5")
+	}
+	// both {4, 5} and {2, 3, 5} with (a + c) are cores.
+	if ids := s.GroupWhy(nil); !reflect.DeepEqual(ids, []int{4, 5}) && !reflect.DeepEqual(ids, []int{2, 3, 5}) {
+		t.Errorf("core %v", ids)
+	}
+	s.AddGroup(5)
+	s.Add(c)
+	s.Add(0)
+	s.EndGroup()
+	if s.Solve() != -1 {
+		t.Fatalf("sat with c and not c")
+	}
+	if ids := s.GroupWhy(nil); !reflect.DeepEqual(ids, []int{5}) {
+		t.Errorf("core %v", ids)
+	}
+	s.RemoveGroup(5)
+	if s.Solve() != 1 {
+		t.Fatalf("unsat without group 5")
+	}
+	if r, _ := s.Test(nil); r == -1 {
+		t.Errorf("test gave %d", r)
+	}
+	s.Untest()
+}
+
+func TestGroupsRand(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	n, m := 30, 180
+	for i := 0; i < 20; i++ {
+		cls := make([][]z.Lit, m)
+		s := NewS()
+		for j := range cls {
+			cl := make([]z.Lit, 3)
+			for k := range cl {
+				cl[k] = z.Var(rng.Intn(n) + 1).Pos()
+				if rng.Intn(2) == 0 {
+					cl[k] = cl[k].Not()
+				}
+			}
+			cls[j] = cl
+			s.AddGroup(j)
+			for _, m := range cl {
+				s.Add(m)
+			}
+			s.Add(0)
+		}
+		s.EndGroup()
+		if s.Solve() != -1 {
+			continue
+		}
+		ids := s.GroupWhy(nil)
+		if len(ids) == 0 {
+			t.Fatalf("empty core")
+		}
+		// the core is unsat by itself.
+		o := NewS()
+		for _, id := range ids {
+			for _, m := range cls[id] {
+				o.Add(m)
+			}
+			o.Add(0)
+		}
+		if o.Solve() != -1 {
+			t.Errorf("core %v sat", ids)
+		}
+		// removing a group of the core may make it sat.
+		s.RemoveGroup(ids[0])
+		if s.Solve() == 0 {
+			t.Errorf("unknown after remove")
+		}
+	}
+}
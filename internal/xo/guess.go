@@ -5,6 +5,7 @@ package xo
 
 import (
 	"math"
+	"math/rand"
 
 	"github.com/go-air/gini/z"
 )
@@ -17,6 +18,7 @@ const (
 	gDecayMax      = float64(0.935)
 	gDecayMaxMax   = float64(0.9875)
 	gDecayMaxDecay = float64(0.9999)
+	gSeedHeat      = float64(0.0001)
 )
 
 type Guess struct {
@@ -37,6 +39,25 @@ type Guess struct {
 	bumpDecay float64
 	bumpLim   float64
 
+	// randomizes initial heat and phases if not nil
+	rng *rand.Rand
+
+	// in focused mode, guesses come from the vmtf queue rather than the
+	// heap, and variables are bumped in the queue rather than heated.
+	focused bool
+	queue   *vmtf
+
+	// phases other than the cached ones, see phases.go.
+	target    []int8
+	best      []int8
+	orig      []int8
+	targetLen int
+	bestLen   int
+	useTarget bool
+
+	// eliminated variables, which are not guessed.
+	removed []bool
+
 	rescales int64
 	guesses  int64
 }
@@ -48,6 +69,13 @@ func newGuess(capHint int) *Guess {
 		vhp:   make([]z.Var, 0, top),
 		heat:  make([]float64, top),
 		cache: make([]int8, top),
+		queue: newVmtf(top),
+
+		target: make([]int8, top),
+		best:   make([]int8, top),
+		orig:   make([]int8, top),
+
+		removed: make([]bool, top),
 
 		decays:        0,
 		restartDecays: 0,
@@ -74,7 +102,9 @@ func NewGuessCdb(cdb *Cdb) *Guess {
 		j := i - 1
 		g.vhp[j] = u
 		g.pos[i] = j
+		g.queue.enqueue(u)
 	}
+	g.queue.reset()
 	inc := 0.001
 	cdb.Forall(func(p z.C, h Chd, ms []z.Lit) {
 		for _, m := range ms {
@@ -88,33 +118,100 @@ func NewGuessCdb(cdb *Cdb) *Guess {
 	return g
 }
 
+// setOptions sets the decay schedule and, if opts.Seed is non-zero,
+// randomizes the heat of the variables.
+func (g *Guess) setOptions(opts *Options) {
+	g.bumpDecay = opts.BumpDecay
+	g.decayMin = opts.DecayMin
+	g.decayMax = opts.DecayMax
+	g.decayMaxMax = opts.DecayMaxMax
+	g.decayMaxDecay = opts.DecayMaxDecay
+	if opts.Seed == 0 {
+		g.rng = nil
+		return
+	}
+	g.rng = rand.New(rand.NewSource(opts.Seed))
+	for i := 1; i < len(g.heat); i++ {
+		g.heat[i] += g.rng.Float64() * gSeedHeat
+	}
+	g.heapify()
+}
+
 // Guess finds the first unassigned variable and returns
 // its cached value.
 func (g *Guess) Guess(vals []int8) z.Lit {
+	if g.focused {
+		v := g.queue.guess(vals)
+		if v == 0 {
+			return z.LitNull
+		}
+		g.guesses++
+		return g.lit(v)
+	}
 	vhp := g.vhp
 	n := len(vhp)
 	var v z.Var
 	for n > 0 {
 		n--
 		v = g.pop()
-		if vals[v.Pos()] == 0 {
+		if vals[v.Pos()] == 0 && !g.removed[v] {
 			g.guesses++
-			switch g.cache[v] {
-			case -1:
-				return v.Neg()
-			case 1:
-				return v.Pos()
-			default:
-				return v.Pos()
-			}
+			return g.lit(v)
 		}
 	}
 	return z.LitNull
 }
 
+func (g *Guess) lit(v z.Var) z.Lit {
+	if g.useTarget && g.target[v] != 0 {
+		if g.target[v] == 1 {
+			return v.Pos()
+		}
+		return v.Neg()
+	}
+	switch g.cache[v] {
+	case -1:
+		return v.Neg()
+	case 1:
+		return v.Pos()
+	default:
+		return v.Pos()
+	}
+}
+
+// eliminate removes the eliminated variable v from the candidates for
+// guessing.
+func (g *Guess) eliminate(v z.Var) {
+	g.removed[v] = true
+	if g.queue.stamps[v] != 0 {
+		g.queue.dequeue(v)
+		g.queue.stamps[v] = 0
+	}
+}
+
+// restore makes the restored variable v a candidate for guessing again.
+func (g *Guess) restore(v z.Var) {
+	g.removed[v] = false
+	if g.cache[v] == -1 {
+		g.Push(v.Neg())
+	} else {
+		g.Push(v.Pos())
+	}
+}
+
+// setFocused sets whether g is in focused mode.
+func (g *Guess) setFocused(focused bool) {
+	g.focused = focused
+	g.queue.reset()
+}
+
 // Bump increases the heat of the variable associated with m
 func (g *Guess) Bump(m z.Lit) bool {
 	v := m.Var()
+	if g.focused {
+		g.queue.bump(v)
+		return false
+	}
 	p := g.pos[v]
 	h := g.heat[v] + g.bumpInc
 	g.heat[v] = h
@@ -142,8 +239,14 @@ func (g *Guess) readStats(st *Stats) {
 	g.rescales = 0
 }
 
-// Decay increases the bump quantity geometrically.
+// Decay increases the bump quantity geometrically.  In focused mode, Decay
+// instead moves the variables bumped since the last call to the end of
+// the queue.
 func (g *Guess) Decay() {
+	if g.focused {
+		g.queue.flush()
+		return
+	}
 	g.decays++
 	rat := float64(g.decays) / float64(g.restartDecays)
 	v := math.Exp(-100.0 * (1.0 - rat))
@@ -192,6 +295,7 @@ func (g *Guess) pop() z.Var {
 func (g *Guess) Push(m z.Lit) {
 	v := m.Var()
 	g.cache[v] = m.Sign()
+	g.queue.unassign(v)
 	if g.pos[v] != -1 {
 		return
 	}
@@ -211,19 +315,43 @@ func (g *Guess) Copy() *Guess {
 		vhp:       make([]z.Var, len(g.vhp), cap(g.vhp)),
 		heat:      make([]float64, len(g.heat), cap(g.heat)),
 		cache:     make([]int8, len(g.cache), cap(g.cache)),
+		focused:   g.focused,
+		queue:     g.queue.Copy(),
+		target:    make([]int8, len(g.target), cap(g.target)),
+		best:      make([]int8, len(g.best), cap(g.best)),
+		orig:      make([]int8, len(g.orig), cap(g.orig)),
+		targetLen: g.targetLen,
+		bestLen:   g.bestLen,
+		useTarget: g.useTarget,
+		removed:   make([]bool, len(g.removed), cap(g.removed)),
+
+		decays:        g.decays,
+		restartDecays: g.restartDecays,
+		decayMax:      g.decayMax,
+		decayMin:      g.decayMin,
+		decayMaxMax:   g.decayMaxMax,
+		decayMaxDecay: g.decayMaxDecay,
+
 		bumpInc:   g.bumpInc,
 		bumpDecay: g.bumpDecay,
 		bumpLim:   g.bumpLim}
+	if g.rng != nil {
+		other.rng = rand.New(rand.NewSource(g.rng.Int63()))
+	}
 	copy(other.pos, g.pos)
 	copy(other.vhp, g.vhp)
 	copy(other.heat, g.heat)
 	copy(other.cache, g.cache)
+	copy(other.target, g.target)
+	copy(other.best, g.best)
+	copy(other.orig, g.orig)
+	copy(other.removed, g.removed)
 	return other
 }
 
 func (g *Guess) has(vals []int8) bool {
 	for _, v := range g.vhp {
-		if vals[v.Pos()] == 0 {
+		if vals[v.Pos()] == 0 && !g.removed[v] {
 			return true
 		}
 	}
@@ -287,20 +415,40 @@ func (g *Guess) growToVar(u z.Var) {
 	copy(d, g.vhp)
 	g.vhp = d
 
+	g.queue.growToVar(u)
 	p := make([]int, w)
 	copy(p, g.pos)
 	for i := len(g.pos); i < int(w); i++ {
 		p[i] = len(g.vhp)
 		g.vhp = append(g.vhp, z.Var(i))
+		g.queue.enqueue(z.Var(i))
 	}
 	g.pos = p
+	g.queue.reset()
 
 	h := make([]float64, w)
 	copy(h, g.heat)
+	if g.rng != nil {
+		for i := len(g.heat); i < int(w); i++ {
+			h[i] = g.rng.Float64() * gSeedHeat
+		}
+	}
 	g.heat = h
 
 	c := make([]int8, w)
 	copy(c, g.cache)
 	g.cache = c
+	c = make([]int8, w)
+	copy(c, g.target)
+	g.target = c
+	c = make([]int8, w)
+	copy(c, g.best)
+	g.best = c
+	c = make([]int8, w)
+	copy(c, g.orig)
+	g.orig = c
+	r := make([]bool, w)
+	copy(r, g.removed)
+	g.removed = r
 	g.heapify()
 }
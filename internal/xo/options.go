@@ -0,0 +1,268 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+// RestartPolicy identifies how S schedules restarts.
+type RestartPolicy int
+
+const (
+	// RestartLuby restarts after a number of conflicts following the Luby
+	// sequence scaled by Options.RestartFactor.
+	RestartLuby RestartPolicy = iota
+	// RestartNone never restarts.
+	RestartNone
+	// RestartGlucose restarts when the average lbd of recently learnt
+	// clauses exceeds the long term average by Options.RestartMargin,
+	// unless blocked because the trail is larger than its average by
+	// Options.RestartBlock.
+	RestartGlucose
+)
+
+// Mode identifies how S guesses variables and schedules restarts.
+type Mode int
+
+const (
+	// ModeStable guesses the most active variables according to a
+	// VSIDS heap and restarts according to Options.Restarts.
+	ModeStable Mode = iota
+	// ModeFocused guesses the most recently bumped variables according
+	// to a VMTF queue and uses glucose style restarts.
+	ModeFocused
+	// ModeSwitch starts in focused mode and switches between focused
+	// and stable mode after Options.ModeConflicts conflicts, doubling the
+	// number of conflicts at each switch.
+	ModeSwitch
+)
+
+// Rephase identifies how S resets its saved phases when rephasing.
+type Rephase int
+
+const (
+	// RephaseOriginal resets to the initial phases, which follow the
+	// polarity of literal occurrences in short clauses.
+	RephaseOriginal Rephase = iota
+	// RephaseInverted resets to the inverted initial phases.
+	RephaseInverted
+	// RephaseBest resets to the values of the longest conflict free
+	// trail since the last RephaseBest.
+	RephaseBest
+	// RephaseRandom resets to random phases.
+	RephaseRandom
+	// RephaseWalk resets to the result of a local search starting from
+	// the saved phases.
+	RephaseWalk
+)
+
+// Options holds the tuning parameters of an S.
+//
+// Zero valued fields are replaced by the corresponding values of
+// DefaultOptions when the options are set.
+//
+// Since zero stands for the default, a field cannot be set to zero where
+// zero would differ from the default.  For example, ReduceFraction,
+// ChronoLevels and TierCore cannot be 0.
+type Options struct {
+	// Restarts is the restart policy.
+	Restarts RestartPolicy
+	// RestartFactor is the number of conflicts in a unit of the Luby
+	// restart sequence.
+	RestartFactor uint
+	// RestartAfter is the minimum number of conflicts before the first
+	// restart of each call to Solve.
+	RestartAfter uint
+	// RestartMargin, RestartBlock and RestartMinConflicts parameterize
+	// the RestartGlucose policy, which restarts at most every
+	// RestartMinConflicts conflicts.
+	RestartMargin       float64
+	RestartBlock        float64
+	RestartMinConflicts uint
+
+	// Mode is the guessing mode, and ModeConflicts the number of
+	// conflicts before the first switch under ModeSwitch.
+	Mode          Mode
+	ModeConflicts uint
+
+	// TargetPhases, if true, makes guesses use the target phases, the
+	// values of the longest conflict free trail since the last rephase,
+	// when they are set.
+	TargetPhases bool
+	// Rephases is a schedule of rephasings, applied cyclically at
+	// restarts.  The k'th rephase occurs at least k*RephaseInterval
+	// conflicts after the previous one.  If Rephases is empty, there is
+	// no rephasing.
+	Rephases        []Rephase
+	RephaseInterval uint
+	// WalkFlips is the maximum number of flips of the local search used
+	// by RephaseWalk.
+	WalkFlips uint
+
+	// Chrono, if true, enables chronological backtracking: conflicts
+	// whose learnt clauses would backjump over more than ChronoLevels
+	// levels backtrack a single level instead, keeping the literals
+	// assigned at lower levels in the trail.
+	Chrono       bool
+	ChronoLevels uint
+
+	// VivifyEffort is the number of propagations spent vivifying tier2
+	// and core learnt clauses, relative to those spent searching, such
+	// as 0.1.  By default, VivifyEffort is 0 and there is no
+	// vivification.  If VivifyIrredundant is true, added clauses are
+	// vivified as well.
+	VivifyEffort      float64
+	VivifyIrredundant bool
+
+	// Eliminate, if true, simplifies the added clauses before solving by
+	// subsumption, self subsuming resolution and bounded variable
+	// elimination.  Variables occurring in more than ElimOccs clauses are
+	// not eliminated.  Eliminated variables which are subsequently used
+	// are restored, see S.Freeze.
+	Eliminate bool
+	ElimOccs  uint
+	// Probe, if true, learns the negations of failed literals, whose
+	// assignment leads to a conflict by unit propagation, before solving.
+	Probe bool
+	// Substitute, if true, replaces literals which are equivalent by
+	// binary clauses with a single representative before solving.
+	Substitute bool
+
+	// BumpDecay is the initial variable activity decay.  During each
+	// restart interval, the decay moves from DecayMin to DecayMax, and
+	// at each restart DecayMax moves towards DecayMaxMax by the factor
+	// DecayMaxDecay.
+	BumpDecay     float64
+	DecayMin      float64
+	DecayMax      float64
+	DecayMaxMax   float64
+	DecayMaxDecay float64
+
+	// ReduceFactor is the number of learnt clauses in a unit of the Luby
+	// sequence scheduling learnt clause database reductions.
+	ReduceFactor uint
+	// ReduceFraction is the fraction of learnt clauses considered for
+	// removal at each reduction.
+	ReduceFraction float64
+	// TierCore and Tier2 are the maximum glue of core learnt clauses,
+	// which are never removed, and of tier2 learnt clauses, which are
+	// kept while they have been used in the last Tier2Window conflicts.
+	TierCore    uint
+	Tier2       uint
+	Tier2Window uint
+
+	// PropTick is the number of propagations between checks for
+	// timeouts and cancellation.
+	PropTick int64
+
+	// Seed, if non-zero, seeds the randomization of the initial
+	// variable order and phases.
+	Seed int64
+}
+
+// DefaultOptions returns the default options.
+func DefaultOptions() *Options {
+	return &Options{
+		Restarts:            RestartLuby,
+		RestartFactor:       RestartFactor,
+		RestartAfter:        RestartAfter,
+		RestartMargin:       gluMargin,
+		RestartBlock:        gluBlock,
+		RestartMinConflicts: gluMinConflicts,
+		Mode:                ModeStable,
+		ModeConflicts:       modeConflicts,
+		TargetPhases:        false,
+		Rephases:            nil,
+		RephaseInterval:     rephaseInterval,
+		WalkFlips:           walkFlips,
+		Chrono:              false,
+		ChronoLevels:        chronoLevels,
+		VivifyEffort:        0,
+		VivifyIrredundant:   false,
+		Eliminate:           false,
+		ElimOccs:            elimOccs,
+		Probe:               false,
+		Substitute:          false,
+		BumpDecay:           gBumpDecay,
+		DecayMin:            gDecayMin,
+		DecayMax:            gDecayMax,
+		DecayMaxMax:         gDecayMaxMax,
+		DecayMaxDecay:       gDecayMaxDecay,
+		ReduceFactor:        cgcFactor,
+		ReduceFraction:      cgcFraction,
+		TierCore:            cgcTierCore,
+		Tier2:               cgcTier2,
+		Tier2Window:         cgcTier2Window,
+		PropTick:            PropTick,
+		Seed:                0}
+}
+
+// withDefaults returns a copy of o with zero fields set to their
+// defaults.
+func (o *Options) withDefaults() *Options {
+	d := DefaultOptions()
+	r := *o
+	if r.RestartFactor == 0 {
+		r.RestartFactor = d.RestartFactor
+	}
+	if r.RestartAfter == 0 {
+		r.RestartAfter = d.RestartAfter
+	}
+	if r.RestartMargin == 0 {
+		r.RestartMargin = d.RestartMargin
+	}
+	if r.RestartBlock == 0 {
+		r.RestartBlock = d.RestartBlock
+	}
+	if r.RestartMinConflicts == 0 {
+		r.RestartMinConflicts = d.RestartMinConflicts
+	}
+	if r.ModeConflicts == 0 {
+		r.ModeConflicts = d.ModeConflicts
+	}
+	r.Rephases = append([]Rephase(nil), o.Rephases...)
+	if r.RephaseInterval == 0 {
+		r.RephaseInterval = d.RephaseInterval
+	}
+	if r.WalkFlips == 0 {
+		r.WalkFlips = d.WalkFlips
+	}
+	if r.ChronoLevels == 0 {
+		r.ChronoLevels = d.ChronoLevels
+	}
+	if r.ElimOccs == 0 {
+		r.ElimOccs = d.ElimOccs
+	}
+	if r.BumpDecay == 0 {
+		r.BumpDecay = d.BumpDecay
+	}
+	if r.DecayMin == 0 {
+		r.DecayMin = d.DecayMin
+	}
+	if r.DecayMax == 0 {
+		r.DecayMax = d.DecayMax
+	}
+	if r.DecayMaxMax == 0 {
+		r.DecayMaxMax = d.DecayMaxMax
+	}
+	if r.DecayMaxDecay == 0 {
+		r.DecayMaxDecay = d.DecayMaxDecay
+	}
+	if r.ReduceFactor == 0 {
+		r.ReduceFactor = d.ReduceFactor
+	}
+	if r.ReduceFraction == 0 {
+		r.ReduceFraction = d.ReduceFraction
+	}
+	if r.TierCore == 0 {
+		r.TierCore = d.TierCore
+	}
+	if r.Tier2 == 0 {
+		r.Tier2 = d.Tier2
+	}
+	if r.Tier2Window == 0 {
+		r.Tier2Window = d.Tier2Window
+	}
+	if r.PropTick == 0 {
+		r.PropTick = d.PropTick
+	}
+	return &r
+}
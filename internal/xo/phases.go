@@ -1,7 +1,19 @@
 package xo
 
-import "github.com/go-air/gini/z"
+import (
+	"math/rand"
 
+	"github.com/go-air/gini/z"
+)
+
+// Type phases records the maximum variable for which initial phases have
+// been set.
+//
+// Besides the cached phases, the last values of unassigned variables,
+// Guess maintains the original phases set by init, the target phases
+// and the best phases.  The target and best phases are the values of the
+// longest conflict free prefix of the trail since the last rephase and
+// since the last best rephase, respectively.
 type phases z.Var
 
 func (p phases) init(s *S) phases {
@@ -31,13 +43,92 @@ func (p phases) init(s *S) phases {
 		}
 	}
 	cache := s.Guess.cache
+	rng := s.Guess.rng
 	for i := z.Var(1); i <= M; i++ {
 		m, n := i.Pos(), i.Neg()
 		if counts[m] > counts[n] {
 			cache[i] = 1
+		} else if counts[m] == counts[n] && rng != nil && rng.Intn(2) == 0 {
+			cache[i] = 1
 		} else {
 			cache[i] = -1
 		}
 	}
+	copy(s.Guess.orig[:M+1], cache[:M+1])
 	return phases(M)
 }
+
+// updatePhases updates the target and best phases given that the
+// assignment ms is free of conflict.
+func (g *Guess) updatePhases(ms []z.Lit) {
+	if len(ms) > g.targetLen {
+		g.targetLen = len(ms)
+		for _, m := range ms {
+			g.target[m.Var()] = m.Sign()
+		}
+	}
+	if len(ms) > g.bestLen {
+		g.bestLen = len(ms)
+		for _, m := range ms {
+			g.best[m.Var()] = m.Sign()
+		}
+	}
+}
+
+// conflictFree returns the prefix of the trail below the current level,
+// which is free of conflict when propagation at the current level gives a
+// conflict.
+func (s *S) conflictFree() []z.Lit {
+	t := s.Trail
+	levels := s.Vars.Levels
+	i := t.Tail
+	for i > 0 && levels[t.D[i-1].Var()] == t.Level {
+		i--
+	}
+	return t.D[:i]
+}
+
+// rephase resets the cached phases according to the next element of the
+// rephasing schedule, and clears the target phases.
+func (s *S) rephase() {
+	g := s.Guess
+	kinds := s.opts.Rephases
+	kind := kinds[s.rephases%int64(len(kinds))]
+	s.rephases++
+	s.rephaseStopwatch = int64(s.opts.RephaseInterval) * (s.rephases + 1)
+	s.stRephases++
+	rng := g.rng
+	if rng == nil {
+		if s.rephaseRng == nil {
+			s.rephaseRng = rand.New(rand.NewSource(rephaseSeed))
+		}
+		rng = s.rephaseRng
+	}
+	cache := g.cache
+	M := s.Vars.Max
+	switch kind {
+	case RephaseOriginal:
+		copy(cache[:M+1], g.orig[:M+1])
+	case RephaseInverted:
+		for i := z.Var(1); i <= M; i++ {
+			cache[i] = -g.orig[i]
+		}
+	case RephaseBest:
+		for i := z.Var(1); i <= M; i++ {
+			if g.best[i] != 0 {
+				cache[i] = g.best[i]
+			}
+		}
+		g.bestLen = 0
+	case RephaseRandom:
+		for i := z.Var(1); i <= M; i++ {
+			cache[i] = int8(2*rng.Intn(2) - 1)
+		}
+	case RephaseWalk:
+		s.walk(rng)
+	}
+	for i := range g.target {
+		g.target[i] = 0
+	}
+	g.targetLen = 0
+}
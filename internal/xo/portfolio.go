@@ -0,0 +1,325 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+const (
+	shareLen = 8 // maximum length of clauses shared in a Portfolio
+	shareLbd = 3 // maximum lbd of clauses shared in a Portfolio
+)
+
+// Portfolio runs differently configured solvers of the same problem
+// concurrently, sharing short learnt clauses between them.  The first
+// solver to find a result gives the result, and the others are stopped.
+//
+// Clauses, assumptions and tests are given to all solvers.  Models,
+// failed assumptions and reasons are those of the solver which gave the
+// last result, or for Test and Reasons, of the first solver.
+//
+// Shared clauses are imported by a solver at restarts, see S.Import.
+//
+// Portfolio implements inter.Sc.
+type Portfolio struct {
+	ss  []*S
+	win *S       // the solver which gave the last result
+	h   *pfSolve // the last GoSolve, if any
+}
+
+// NewPortfolio creates a portfolio of n solvers, configured with
+// PortfolioOptions.
+func NewPortfolio(n int) *Portfolio {
+	if n < 1 {
+		n = 1
+	}
+	ss := make([]*S, n)
+	for i := range ss {
+		ss[i] = NewS()
+		ss[i].SetOptions(PortfolioOptions(i))
+	}
+	return newPortfolio(ss)
+}
+
+func newPortfolio(ss []*S) *Portfolio {
+	p := &Portfolio{ss: ss, win: ss[0]}
+	for i, s := range ss {
+		i := i
+		s.SetExport(shareLen, shareLbd, func(ms []z.Lit, lbd int) {
+			for j, o := range p.ss {
+				if j != i {
+					o.Import(ms)
+				}
+			}
+		})
+	}
+	return p
+}
+
+// portfolioOptions are the options of the first solvers of a Portfolio.
+var portfolioOptions = []Options{
+	{},
+	{Mode: ModeSwitch, TargetPhases: true,
+		Rephases: []Rephase{RephaseOriginal, RephaseBest, RephaseWalk, RephaseInverted, RephaseBest, RephaseRandom}},
+	{Mode: ModeFocused, Chrono: true},
+	{Restarts: RestartGlucose, Seed: 3},
+	{Mode: ModeSwitch, Restarts: RestartGlucose, TargetPhases: true,
+		Rephases: []Rephase{RephaseBest, RephaseWalk}, Seed: 4},
+	{Mode: ModeFocused, TargetPhases: true, Rephases: []Rephase{RephaseOriginal, RephaseRandom}, Seed: 5},
+	{Chrono: true, ChronoLevels: 1, Seed: 6},
+	{Mode: ModeSwitch, Chrono: true, Seed: 7}}
+
+// PortfolioOptions returns the options of the i'th solver of a Portfolio.
+// Beyond the first few, the options repeat with different seeds.
+func PortfolioOptions(i int) *Options {
+	o := portfolioOptions[i%len(portfolioOptions)]
+	if i >= len(portfolioOptions) {
+		o.Seed = int64(i)
+	}
+	return o.withDefaults()
+}
+
+// Len returns the number of solvers in p.
+func (p *Portfolio) Len() int {
+	return len(p.ss)
+}
+
+func (p *Portfolio) MaxVar() z.Var {
+	return p.ss[0].MaxVar()
+}
+
+func (p *Portfolio) Lit() z.Lit {
+	m := p.ss[0].Lit()
+	for _, s := range p.ss[1:] {
+		s.ensureLitCap(m)
+	}
+	return m
+}
+
+func (p *Portfolio) Add(m z.Lit) {
+	for _, s := range p.ss {
+		s.Add(m)
+	}
+}
+
+func (p *Portfolio) Assume(ms ...z.Lit) {
+	for _, s := range p.ss {
+		s.Assume(ms...)
+	}
+}
+
+func (p *Portfolio) Solve() int {
+	return p.GoSolve().Wait()
+}
+
+func (p *Portfolio) Try(dur time.Duration) int {
+	return p.GoSolve().Try(dur)
+}
+
+// SolveContext solves until ctx is done, returning 0 if ctx is done
+// before a result is found.
+func (p *Portfolio) SolveContext(ctx context.Context) int {
+	return p.GoSolve().SolveContext(ctx)
+}
+
+// GoSolve starts the solvers of p, each in its own goroutine, and returns
+// a connection to them.
+func (p *Portfolio) GoSolve() inter.Solve {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &pfSolve{
+		p:      p,
+		cancel: cancel,
+		dones:  make([]chan struct{}, len(p.ss)),
+		paused: make([]bool, len(p.ss)),
+		res:    make(chan int, 1)}
+	type result struct {
+		s *S
+		r int
+	}
+	rs := make(chan result, len(p.ss))
+	for i, s := range p.ss {
+		done := make(chan struct{})
+		h.dones[i] = done
+		go func(s *S) {
+			r := s.SolveContext(ctx)
+			close(done)
+			rs <- result{s, r}
+		}(s)
+	}
+	go func() {
+		res, win := 0, p.ss[0]
+		for range p.ss {
+			x := <-rs
+			if x.r != 0 && res == 0 {
+				res, win = x.r, x.s
+				cancel()
+			}
+		}
+		cancel()
+		p.win = win
+		h.res <- res
+	}()
+	p.h = h
+	return h
+}
+
+func (p *Portfolio) Value(m z.Lit) bool {
+	return p.win.Value(m)
+}
+
+func (p *Portfolio) Why(ms []z.Lit) []z.Lit {
+	return p.win.Why(ms)
+}
+
+// Test tests all solvers of p, returning the propagated literals of the
+// first.  The result is -1 if any solver is inconsistent under the
+// assumptions.
+func (p *Portfolio) Test(dst []z.Lit) (res int, out []z.Lit) {
+	for _, s := range p.ss[1:] {
+		if r, _ := s.Test(nil); r == -1 {
+			res = -1
+		}
+	}
+	r, out := p.ss[0].Test(dst)
+	if res == 0 {
+		res = r
+	}
+	return res, out
+}
+
+func (p *Portfolio) Untest() int {
+	res := 0
+	for _, s := range p.ss {
+		if s.Untest() == -1 {
+			res = -1
+		}
+	}
+	return res
+}
+
+func (p *Portfolio) Reasons(dst []z.Lit, m z.Lit) []z.Lit {
+	return p.ss[0].Reasons(dst, m)
+}
+
+// SCopy copies the solvers of p into a new Portfolio.
+func (p *Portfolio) SCopy() inter.S {
+	ss := make([]*S, len(p.ss))
+	for i, s := range p.ss {
+		ss[i] = s.Copy()
+	}
+	return newPortfolio(ss)
+}
+
+// Stop stops the last GoSolve of p, if it is running.
+func (p *Portfolio) Stop() {
+	if p.h != nil {
+		p.h.Stop()
+	}
+}
+
+// pfSolve is a connection to the solvers of a Portfolio started by
+// GoSolve, implementing inter.Solve.
+type pfSolve struct {
+	p      *Portfolio
+	cancel context.CancelFunc
+	dones  []chan struct{} // closed when each solver returns
+	paused []bool
+	res    chan int
+	r      int
+	done   bool
+}
+
+// result returns the result, waiting for it if wait is true, and whether
+// it is available.
+func (h *pfSolve) result(wait bool) (int, bool) {
+	if h.done {
+		return h.r, true
+	}
+	if wait {
+		h.r = <-h.res
+		h.done = true
+		return h.r, true
+	}
+	select {
+	case h.r = <-h.res:
+		h.done = true
+		return h.r, true
+	default:
+		return 0, false
+	}
+}
+
+func (h *pfSolve) Stop() int {
+	h.cancel()
+	r, _ := h.result(true)
+	return r
+}
+
+func (h *pfSolve) Try(d time.Duration) int {
+	if h.done {
+		return h.r
+	}
+	select {
+	case h.r = <-h.res:
+		h.done = true
+		return h.r
+	case <-time.After(d):
+		return h.Stop()
+	}
+}
+
+func (h *pfSolve) SolveContext(ctx context.Context) int {
+	if h.done {
+		return h.r
+	}
+	select {
+	case h.r = <-h.res:
+		h.done = true
+		return h.r
+	case <-ctx.Done():
+		return h.Stop()
+	}
+}
+
+func (h *pfSolve) Test() (int, bool) {
+	return h.result(false)
+}
+
+// Pause pauses the solvers which are still running.
+func (h *pfSolve) Pause() (int, bool) {
+	if r, ok := h.result(false); ok {
+		return r, false
+	}
+	for i, s := range h.p.ss {
+		c := s.control
+		select {
+		case c.cStopOrPause <- false:
+			s.rmu.Unlock()
+			h.paused[i] = true
+		case <-h.dones[i]:
+		}
+	}
+	return 0, true
+}
+
+func (h *pfSolve) Unpause() {
+	for i, s := range h.p.ss {
+		if !h.paused[i] {
+			continue
+		}
+		s.rmu.Lock()
+		<-s.control.cStopOrPause
+		h.paused[i] = false
+	}
+}
+
+func (h *pfSolve) Wait() int {
+	r, _ := h.result(true)
+	return r
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+func TestPortfolio(t *testing.T) {
+	var _ inter.Sc = NewPortfolio(1)
+	rng := rand.New(rand.NewSource(41))
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 100, 400+rng.Intn(40))
+		p := NewPortfolio(1 + i%9)
+		o := NewS()
+		for _, ms := range cnf {
+			for _, m := range ms {
+				p.Add(m)
+			}
+			p.Add(z.LitNull)
+		}
+		addCnf(o, cnf)
+		for j := 0; j < 4; j++ {
+			a := z.Var(rng.Intn(100) + 1).Pos()
+			o.Assume(a)
+			p.Assume(a)
+			r := p.Solve()
+			if r != o.Solve() {
+				t.Fatalf("portfolio result %d", r)
+			}
+			switch r {
+			case 1:
+				checkCnf(t, p.win, cnf)
+				if !p.Value(a) {
+					t.Errorf("assumption false")
+				}
+			case -1:
+				if why := p.Why(nil); len(why) > 1 || len(why) == 1 && why[0] != a {
+					t.Errorf("why %v", why)
+				}
+			}
+		}
+		p.Stop()
+	}
+}
+
+func TestPortfolioShare(t *testing.T) {
+	// solves long enough for restarts, where shared clauses are imported
+	// also under assumptions.
+	rng := rand.New(rand.NewSource(43))
+	st := NewStats()
+	for i := 0; i < 2; i++ {
+		cnf := elimCnf(rng, 200, 840+rng.Intn(40))
+		p := NewPortfolio(4)
+		o := NewS()
+		for _, ms := range cnf {
+			for _, m := range ms {
+				p.Add(m)
+			}
+			p.Add(z.LitNull)
+		}
+		addCnf(o, cnf)
+		a := z.Var(rng.Intn(200) + 1).Pos()
+		o.Assume(a)
+		p.Assume(a)
+		r := p.Solve()
+		if r != o.Solve() {
+			t.Fatalf("portfolio result %d", r)
+		}
+		if r == 1 {
+			checkCnf(t, p.win, cnf)
+		}
+		for _, s := range p.ss {
+			s.ReadStats(st)
+		}
+		p.Stop()
+	}
+	if st.Imported == 0 {
+		t.Errorf("nothing shared")
+	}
+}
+
+func TestPortfolioTest(t *testing.T) {
+	p := NewPortfolio(3)
+	gen.BinCycle(p, 10)
+	p.Assume(z.Var(4).Pos())
+	r, ms := p.Test([]z.Lit{})
+	if r != 1 || len(ms) != 10 {
+		t.Fatalf("test %d %v", r, ms)
+	}
+	if p.Solve() != 1 {
+		t.Errorf("not sat under test")
+	}
+	for i := 1; i <= 10; i++ {
+		if !p.Value(z.Var(i).Pos()) {
+			t.Errorf("%d false under test", i)
+		}
+	}
+	if p.Untest() != 0 {
+		t.Errorf("untest unsat")
+	}
+	p.Assume(z.Var(1).Pos(), z.Var(2).Neg())
+	if p.Solve() != -1 {
+		t.Errorf("not unsat")
+	}
+	if p.Solve() != 1 {
+		t.Errorf("not sat without assumptions")
+	}
+}
+
+func TestPortfolioStop(t *testing.T) {
+	p := NewPortfolio(4)
+	gen.Php(p, 12, 11)
+	c := p.GoSolve()
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		if res, ok := c.Pause(); !ok {
+			t.Fatalf("solved hard php: %d", res)
+		}
+		c.Unpause()
+	}
+	if r := c.Try(20 * time.Millisecond); r != 0 {
+		t.Errorf("solved hard php: %d", r)
+	}
+	if r := p.Try(10 * time.Millisecond); r != 0 {
+		t.Errorf("solved hard php: %d", r)
+	}
+	p.Stop()
+	q := p.SCopy()
+	q.Assume(z.Var(1).Pos(), z.Var(1).Neg())
+	if q.Solve() != -1 {
+		t.Errorf("copy not unsat")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import "github.com/go-air/gini/z"
+
+// propagations per probing pass.
+const probeMaxProps = 5000000
+
+// binaryOccs returns the number of binary clauses containing m.
+func (s *S) binaryOccs(m z.Lit) int {
+	n := 0
+	for _, w := range s.Vars.Watches[m] {
+		if w.IsBinary() {
+			n++
+		}
+	}
+	return n
+}
+
+// probe looks for failed literals, literals whose assignment at level 0
+// leads to a conflict under unit propagation, and learns their negations
+// as units.  The probed literals are the roots of the binary implication
+// graph, which imply some literal by a binary clause and are implied by
+// none.
+//
+// probe returns CNull, or a conflict at level 0 if a learnt unit leads to
+// one.
+func (s *S) probe() z.C {
+	trail := s.Trail
+	cdb := s.Cdb
+	vals := s.Vars.Vals
+	elimd := s.elim.elimd
+	start := trail.Props
+	// decisions during probing must not change the saved phases.
+	cache := append([]int8(nil), s.Guess.cache...)
+	defer copy(s.Guess.cache, cache)
+
+	var cands []z.Lit
+	for v := z.Var(1); v <= s.Vars.Max; v++ {
+		if elimd[v] || vals[v.Pos()] != 0 {
+			continue
+		}
+		for _, m := range [...]z.Lit{v.Pos(), v.Neg()} {
+			if s.binaryOccs(m) == 0 && s.binaryOccs(m.Not()) != 0 {
+				cands = append(cands, m)
+			}
+		}
+	}
+	for _, m := range cands {
+		if trail.Props-start > probeMaxProps {
+			break
+		}
+		if vals[m] != 0 {
+			continue
+		}
+		s.stProbed++
+		trail.Assign(m, CNull)
+		x := trail.Prop()
+		trail.Back(0)
+		if x == CNull {
+			continue
+		}
+		s.stFailedLits++
+		p := cdb.Learn([]z.Lit{m.Not()}, 0)
+		trail.Assign(m.Not(), p)
+		if x := trail.Prop(); x != CNull {
+			return x
+		}
+	}
+	return CNull
+}
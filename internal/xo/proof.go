@@ -0,0 +1,276 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/go-air/gini/z"
+)
+
+// ProofFormat identifies an output format for proofs of unsatisfiability.
+type ProofFormat int
+
+const (
+	// ProofDrat is the textual DRAT format.
+	ProofDrat ProofFormat = iota
+	// ProofDratBinary is the binary DRAT format.
+	ProofDratBinary
+	// ProofLrat is the textual LRAT format.
+	ProofLrat
+	// ProofLratBinary is the binary LRAT format.
+	ProofLratBinary
+)
+
+// IsLrat returns whether f is an LRAT format, which requires hints.
+func (f ProofFormat) IsLrat() bool {
+	return f == ProofLrat || f == ProofLratBinary
+}
+
+func (f ProofFormat) isBinary() bool {
+	return f == ProofDratBinary || f == ProofLratBinary
+}
+
+// lemma ids are tagged with lemmaBit until the number of input
+// clauses is known, at which point they are numbered after them.
+const lemmaBit = uint64(1) << 62
+
+var (
+	errLratInput = errors.New("lrat proof: clause added after proof output started")
+	errLratHint  = errors.New("lrat proof: hint without clause id")
+)
+
+// Type proof is a Tracer which writes DRAT or LRAT proofs.
+type proof struct {
+	w      *bufio.Writer
+	format ProofFormat
+	buf    []byte
+	err    error
+	done   bool // true once the empty clause is written
+
+	// lrat clause ids
+	ids     map[z.C]uint64
+	nIn     uint64 // number of input clauses
+	nLemmas uint64 // number of lemmas
+	lastIn  uint64 // id of the last input clause
+	started bool   // true once nIn is fixed and steps are written
+	steps   []step // steps buffered until started
+	hs      []uint64
+}
+
+// Type step is a proof step.  For deletions, id is that of the last
+// clause added before the step.
+type step struct {
+	op  byte
+	id  uint64
+	ms  []z.Lit
+	ids []uint64
+}
+
+func newProof(w io.Writer, f ProofFormat) *proof {
+	p := &proof{
+		w:      bufio.NewWriter(w),
+		format: f,
+		buf:    make([]byte, 0, 128)}
+	if f.IsLrat() {
+		p.ids = make(map[z.C]uint64, 1024)
+	}
+	return p
+}
+
+// Input implements Tracer.
+func (p *proof) Input(c z.C) {
+	if p.ids == nil {
+		return
+	}
+	if p.started {
+		p.setErr(errLratInput)
+		return
+	}
+	p.nIn++
+	p.lastIn = p.nIn
+	if c != CInf && c != CNull {
+		p.ids[c] = p.nIn
+	}
+}
+
+// Add implements Tracer.
+func (p *proof) Add(c z.C, ms []z.Lit, hints []z.C) {
+	if p.done {
+		return
+	}
+	if len(ms) == 0 {
+		p.done = true
+	}
+	if p.ids == nil {
+		p.write(step{op: 'a', ms: ms})
+		return
+	}
+	p.nLemmas++
+	id := p.nLemmas | lemmaBit
+	hs := p.hs[:0]
+	for _, h := range hints {
+		if h == CInf {
+			hs = append(hs, p.lastIn)
+			continue
+		}
+		hid, ok := p.ids[h]
+		if !ok {
+			p.setErr(errLratHint)
+			return
+		}
+		hs = append(hs, hid)
+	}
+	p.hs = hs
+	if c != CNull {
+		p.ids[c] = id
+	}
+	p.step(step{op: 'a', id: id, ms: ms, ids: hs})
+}
+
+// Remove implements Tracer.
+func (p *proof) Remove(c z.C, ms []z.Lit) {
+	if p.done {
+		return
+	}
+	if p.ids == nil {
+		p.write(step{op: 'd', ms: ms})
+		return
+	}
+	id, ok := p.ids[c]
+	if !ok {
+		return
+	}
+	delete(p.ids, c)
+	p.hs = append(p.hs[:0], id)
+	p.step(step{op: 'd', id: p.nLemmas | lemmaBit, ids: p.hs})
+}
+
+// Relocate implements Tracer.
+func (p *proof) Relocate(rlm map[z.C]z.C) {
+	if p.ids == nil {
+		return
+	}
+	ids := make(map[z.C]uint64, len(p.ids))
+	for c, id := range p.ids {
+		if d, ok := rlm[c]; ok {
+			if d == CNull {
+				continue
+			}
+			c = d
+		}
+		ids[c] = id
+	}
+	p.ids = ids
+}
+
+// step writes s if output has started and buffers a copy of it
+// otherwise.
+func (p *proof) step(s step) {
+	if p.started {
+		p.write(s)
+		return
+	}
+	s.ms = append([]z.Lit(nil), s.ms...)
+	s.ids = append([]uint64(nil), s.ids...)
+	p.steps = append(p.steps, s)
+}
+
+// start fixes the number of input clauses and writes buffered steps.
+func (p *proof) start() {
+	p.started = true
+	for _, s := range p.steps {
+		p.write(s)
+	}
+	p.steps = nil
+}
+
+// id returns the output id for internal id u.
+func (p *proof) id(u uint64) uint64 {
+	if u&lemmaBit != 0 {
+		return p.nIn + u&^lemmaBit
+	}
+	return u
+}
+
+func (p *proof) write(s step) {
+	if p.err != nil {
+		return
+	}
+	buf := p.buf[:0]
+	lrat := p.ids != nil
+	if p.format.isBinary() {
+		// the binary formats code literals exactly as z.Lit, and
+		// clause ids as positive signed numbers.
+		buf = append(buf, s.op)
+		if lrat && s.op == 'a' {
+			buf = appendVarint(buf, 2*p.id(s.id))
+		}
+		for _, m := range s.ms {
+			buf = appendVarint(buf, uint64(m))
+		}
+		if lrat && s.op == 'a' {
+			buf = append(buf, 0)
+		}
+		for _, u := range s.ids {
+			buf = appendVarint(buf, 2*p.id(u))
+		}
+		buf = append(buf, 0)
+	} else {
+		if lrat {
+			buf = strconv.AppendUint(buf, p.id(s.id), 10)
+			buf = append(buf, ' ')
+		}
+		if s.op == 'd' {
+			buf = append(buf, 'd', ' ')
+		}
+		for _, m := range s.ms {
+			buf = strconv.AppendInt(buf, int64(m.Dimacs()), 10)
+			buf = append(buf, ' ')
+		}
+		if lrat && s.op == 'a' {
+			buf = append(buf, '0', ' ')
+		}
+		for _, u := range s.ids {
+			buf = strconv.AppendUint(buf, p.id(u), 10)
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, '0', '\n')
+	}
+	_, e := p.w.Write(buf)
+	p.setErr(e)
+	p.buf = buf
+}
+
+func (p *proof) setErr(e error) {
+	if p.err == nil {
+		p.err = e
+	}
+}
+
+// flush flushes buffered proof output and returns the
+// first error encountered writing the proof, if any.
+//
+// For LRAT proofs, the first flush fixes the number of input clauses.
+func (p *proof) flush() error {
+	if !p.started {
+		p.start()
+	}
+	if p.err != nil {
+		return p.err
+	}
+	p.err = p.w.Flush()
+	return p.err
+}
+
+func appendVarint(buf []byte, u uint64) []byte {
+	for u >= 0x80 {
+		buf = append(buf, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(buf, byte(u))
+}
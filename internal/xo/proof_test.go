@@ -0,0 +1,409 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"bufio"
+	"bytes"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-air/gini/gen"
+	chk "github.com/go-air/gini/proof"
+	"github.com/go-air/gini/z"
+)
+
+type clsRec struct {
+	cur []z.Lit
+	cs  [][]z.Lit
+}
+
+func (c *clsRec) Add(m z.Lit) {
+	if m == z.LitNull {
+		c.cs = append(c.cs, c.cur)
+		c.cur = nil
+		return
+	}
+	c.cur = append(c.cur, m)
+}
+
+// rup checks naively whether ms is implied by unit propagation on cs.
+func rup(cs [][]z.Lit, ms []z.Lit) bool {
+	vals := make(map[z.Lit]bool)
+	for _, m := range ms {
+		vals[m.Not()] = true
+	}
+	for {
+		changed := false
+		for _, c := range cs {
+			var unit z.Lit
+			n := 0
+			sat := false
+			for _, m := range c {
+				if vals[m] {
+					sat = true
+					break
+				}
+				if !vals[m.Not()] {
+					unit = m
+					n++
+				}
+			}
+			if sat {
+				continue
+			}
+			if n == 0 {
+				return true
+			}
+			if n == 1 {
+				vals[unit] = true
+				changed = true
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+}
+
+func parseDrat(t *testing.T, dat []byte) [][]z.Lit {
+	res := [][]z.Lit{}
+	sc := bufio.NewScanner(bytes.NewReader(dat))
+	for sc.Scan() {
+		fs := strings.Fields(sc.Text())
+		ms := []z.Lit{}
+		if fs[0] == "d" {
+			ms = append(ms, z.LitNull)
+			fs = fs[1:]
+		}
+		for _, f := range fs[:len(fs)-1] {
+			i, e := strconv.Atoi(f)
+			if e != nil {
+				t.Fatal(e)
+			}
+			ms = append(ms, z.Dimacs2Lit(i))
+		}
+		res = append(res, ms)
+	}
+	return res
+}
+
+func TestProofDrat(t *testing.T) {
+	rec := &clsRec{}
+	gen.Php(rec, 6, 5)
+	cs := rec.cs
+	txt, bin := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	for _, buf := range []*bytes.Buffer{txt, bin} {
+		s := NewS()
+		if buf == txt {
+			s.SetProof(buf, ProofDrat)
+		} else {
+			s.SetProof(buf, ProofDratBinary)
+		}
+		gen.Php(s, 6, 5)
+		if s.Solve() != -1 {
+			t.Fatalf("php not unsat")
+		}
+		if e := s.ProofError(); e != nil {
+			t.Fatal(e)
+		}
+	}
+	steps := parseDrat(t, txt.Bytes())
+	if len(steps) == 0 || len(steps[len(steps)-1]) != 0 {
+		t.Fatalf("proof does not end with the empty clause")
+	}
+	var bbuf []byte
+	for _, ms := range steps {
+		if len(ms) > 0 && ms[0] == z.LitNull {
+			bbuf = append(bbuf, 'd')
+			ms = ms[1:]
+		} else {
+			bbuf = append(bbuf, 'a')
+		}
+		for _, m := range ms {
+			bbuf = appendVarint(bbuf, uint64(m))
+		}
+		bbuf = append(bbuf, 0)
+	}
+	if !bytes.Equal(bbuf, bin.Bytes()) {
+		t.Errorf("binary and text proofs differ")
+	}
+	for i, ms := range steps {
+		if len(ms) > 0 && ms[0] == z.LitNull {
+			continue
+		}
+		if !rup(cs, ms) {
+			t.Fatalf("step %d: %v not rup", i, ms)
+		}
+		cs = append(cs, ms)
+	}
+}
+
+type lratStep struct {
+	id    int
+	del   bool
+	ms    []z.Lit
+	hints []int
+}
+
+func parseLrat(t *testing.T, dat []byte) []lratStep {
+	res := []lratStep{}
+	sc := bufio.NewScanner(bytes.NewReader(dat))
+	for sc.Scan() {
+		fs := strings.Fields(sc.Text())
+		is := make([]int, 0, len(fs))
+		st := lratStep{}
+		for i, f := range fs {
+			if i == 1 && f == "d" {
+				st.del = true
+				continue
+			}
+			n, e := strconv.Atoi(f)
+			if e != nil {
+				t.Fatal(e)
+			}
+			is = append(is, n)
+		}
+		st.id = is[0]
+		is = is[1:]
+		if !st.del {
+			for len(is) > 0 && is[0] != 0 {
+				st.ms = append(st.ms, z.Dimacs2Lit(is[0]))
+				is = is[1:]
+			}
+			is = is[1:]
+		}
+		st.hints = is[:len(is)-1]
+		res = append(res, st)
+	}
+	return res
+}
+
+// checkHints checks that ms follows from the hint clauses by unit
+// propagation in the given order.
+func checkHints(cs map[int][]z.Lit, ms []z.Lit, hints []int) bool {
+	vals := make(map[z.Lit]bool)
+	for _, m := range ms {
+		vals[m.Not()] = true
+	}
+	for _, h := range hints {
+		c, ok := cs[h]
+		if !ok {
+			return false
+		}
+		unit := z.LitNull
+		n := 0
+		for _, m := range c {
+			if vals[m] {
+				return false
+			}
+			if !vals[m.Not()] {
+				unit = m
+				n++
+			}
+		}
+		switch n {
+		case 0:
+			return true
+		case 1:
+			vals[unit] = true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func TestProofLrat(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		rec := &clsRec{}
+		switch i {
+		case 0:
+			gen.Php(rec, 6, 5)
+		case 1:
+			// repeated literals false at level 0 shorten the
+			// input clauses.
+			f := z.Var(61).Pos()
+			rec.Add(f.Not())
+			rec.Add(0)
+			gen.Rand3Cnf(rec, 60, 300)
+			for j := 1; j < len(rec.cs); j++ {
+				rec.cs[j] = append(rec.cs[j], f, f)
+			}
+		default:
+			gen.Rand3Cnf(rec, 60, 300)
+		}
+		txt, bin := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+		res := 0
+		for _, buf := range []*bytes.Buffer{txt, bin} {
+			s := NewS()
+			if buf == txt {
+				s.SetProof(buf, ProofLrat)
+			} else {
+				s.SetProof(buf, ProofLratBinary)
+			}
+			for _, c := range rec.cs {
+				for _, m := range c {
+					s.Add(m)
+				}
+				s.Add(0)
+			}
+			res = s.Solve()
+			if e := s.ProofError(); e != nil {
+				t.Fatal(e)
+			}
+		}
+		if res != -1 {
+			continue
+		}
+		steps := parseLrat(t, txt.Bytes())
+		var bbuf []byte
+		for _, st := range steps {
+			if st.del {
+				bbuf = append(bbuf, 'd')
+			} else {
+				bbuf = append(bbuf, 'a')
+				bbuf = appendVarint(bbuf, 2*uint64(st.id))
+				for _, m := range st.ms {
+					bbuf = appendVarint(bbuf, uint64(m))
+				}
+				bbuf = append(bbuf, 0)
+			}
+			for _, h := range st.hints {
+				bbuf = appendVarint(bbuf, 2*uint64(h))
+			}
+			bbuf = append(bbuf, 0)
+		}
+		if !bytes.Equal(bbuf, bin.Bytes()) {
+			t.Errorf("%d: binary and text proofs differ", i)
+		}
+		c := chk.NewChecker()
+		for _, ms := range rec.cs {
+			for _, m := range ms {
+				c.Add(m)
+			}
+			c.Add(0)
+		}
+		if e := c.Check(bytes.NewReader(txt.Bytes()), chk.Lrat); e != nil {
+			t.Errorf("%d: %s", i, e)
+		}
+		cs := make(map[int][]z.Lit)
+		for j, c := range rec.cs {
+			cs[j+1] = c
+		}
+		last := len(rec.cs)
+		empty := false
+		for j, st := range steps {
+			if st.del {
+				for _, h := range st.hints {
+					if _, ok := cs[h]; !ok {
+						t.Fatalf("%d: step %d: delete unknown clause %d", i, j, h)
+					}
+					delete(cs, h)
+				}
+				continue
+			}
+			if st.id <= last {
+				t.Fatalf("%d: step %d: id %d not increasing", i, j, st.id)
+			}
+			last = st.id
+			if !checkHints(cs, st.ms, st.hints) {
+				t.Fatalf("%d: step %d: %v hints %v invalid", i, j, st.ms, st.hints)
+			}
+			cs[st.id] = st.ms
+			empty = len(st.ms) == 0
+		}
+		if !empty {
+			t.Fatalf("%d: proof does not end with the empty clause", i)
+		}
+	}
+}
+
+func TestProofIncremental(t *testing.T) {
+	opts := []Options{
+		{Eliminate: true},
+		{Substitute: true},
+		{Probe: true, Substitute: true}}
+	rng := rand.New(rand.NewSource(3))
+	for k, o := range opts {
+		for _, f := range []ProofFormat{ProofDrat, ProofDratBinary} {
+			unsats := 0
+			for i := 0; i < 30; i++ {
+				cnf := elimCnf(rng, 40, 60)
+				buf := bytes.NewBuffer(nil)
+				s := NewS()
+				opt := o
+				s.SetOptions(&opt)
+				s.SetProof(buf, f)
+				addCnf(s, cnf)
+				res := s.Solve()
+				for j := 0; j < 8 && res == 1; j++ {
+					// clauses over possibly eliminated or
+					// substituted variables.
+					more := elimCnf(rng, 50, 10)
+					for l := 0; l < 2; l++ {
+						a := z.Var(rng.Intn(50) + 1).Pos()
+						b := z.Var(rng.Intn(50) + 1).Pos()
+						if a.Var() != b.Var() {
+							more = append(more, []z.Lit{a.Not(), b}, []z.Lit{a, b.Not()})
+						}
+					}
+					addCnf(s, more)
+					cnf = append(cnf, more...)
+					res = s.Solve()
+				}
+				if e := s.ProofError(); e != nil {
+					t.Fatal(e)
+				}
+				if res != -1 {
+					continue
+				}
+				unsats++
+				c := chk.NewChecker()
+				for _, ms := range cnf {
+					for _, m := range ms {
+						c.Add(m)
+					}
+					c.Add(0)
+				}
+				if e := c.Check(bytes.NewReader(buf.Bytes()), chk.Drat); e != nil {
+					t.Errorf("%d/%d/%d: %s", k, f, i, e)
+				}
+			}
+			if unsats == 0 {
+				t.Errorf("%d/%d: no unsat instances", k, f)
+			}
+		}
+	}
+}
+
+func TestProofLratIds(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	p := newProof(buf, ProofLrat)
+	a, b, c := z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos()
+	for i := 1; i <= 5; i++ {
+		p.Input(z.C(10 * i))
+	}
+	// deletions are buffered along with the lemmas until the flush.
+	p.Remove(z.C(50), []z.Lit{a, b, c})
+	p.Add(z.C(60), []z.Lit{a}, []z.C{10, 20})
+	p.Remove(z.C(10), []z.Lit{a, b})
+	p.Add(CNull, nil, []z.C{60, 30, 40})
+	if e := p.flush(); e != nil {
+		t.Fatal(e)
+	}
+	exp := "5 d 5 0\n6 1 0 1 2 0\n6 d 1 0\n7 0 6 3 4 0\n"
+	if buf.String() != exp {
+		t.Errorf("got\n%swant\n%s", buf.String(), exp)
+	}
+
+	p = newProof(bytes.NewBuffer(nil), ProofLrat)
+	p.Input(z.C(10))
+	p.Add(z.C(20), []z.Lit{a}, []z.C{10, 30})
+	if e := p.flush(); e != errLratHint {
+		t.Errorf("hint without id gave %v", e)
+	}
+}
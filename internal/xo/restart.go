@@ -0,0 +1,89 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+const (
+	gluMargin       = 1.25
+	gluBlock        = 1.4
+	gluMinConflicts = 50
+
+	gluFastAlpha  = 1.0 / 32
+	gluSlowAlpha  = 1.0 / 100000
+	gluTrailAlpha = 1.0 / 5000
+	gluBlockAfter = 10000
+)
+
+// Type ema is an exponential moving average.  The first values are
+// averaged with larger weights, so that the average is not biased by its
+// initial value.
+type ema struct {
+	val   float64
+	alpha float64
+	n     int64
+}
+
+func (e *ema) update(x float64) {
+	e.n++
+	a := 1.0 / float64(e.n)
+	if a < e.alpha {
+		a = e.alpha
+	}
+	e.val += a * (x - e.val)
+}
+
+// Type gluRestarts implements glucose style dynamic restarts: a restart is
+// triggered when the recent average lbd of learnt clauses is high relative
+// to the long term average, and blocked when the trail is large relative to
+// its average, since the solver may then be approaching a model.
+type gluRestarts struct {
+	fast, slow, trail ema
+
+	conflicts    int64 // since last restart or block
+	total        int64
+	margin       float64
+	block        float64
+	minConflicts int64
+
+	stBlocks int64
+}
+
+func newGluRestarts(opts *Options) *gluRestarts {
+	return &gluRestarts{
+		fast:         ema{alpha: gluFastAlpha},
+		slow:         ema{alpha: gluSlowAlpha},
+		trail:        ema{alpha: gluTrailAlpha},
+		margin:       opts.RestartMargin,
+		block:        opts.RestartBlock,
+		minConflicts: int64(opts.RestartMinConflicts)}
+}
+
+// conflict records a conflict with a trail of length trail, resulting in
+// a learnt clause with lbd lbd.
+func (g *gluRestarts) conflict(lbd, trail int) {
+	g.total++
+	g.conflicts++
+	g.fast.update(float64(lbd))
+	g.slow.update(float64(lbd))
+	t := float64(trail)
+	if g.total > gluBlockAfter && g.conflicts >= g.minConflicts && t > g.block*g.trail.val {
+		g.conflicts = 0
+		g.stBlocks++
+	}
+	g.trail.update(t)
+}
+
+// ready returns whether a restart should occur.
+func (g *gluRestarts) ready() bool {
+	return g.conflicts >= g.minConflicts && g.fast.val > g.margin*g.slow.val
+}
+
+// restart is called when a restart occurs.
+func (g *gluRestarts) restart() {
+	g.conflicts = 0
+}
+
+func (g *gluRestarts) readStats(st *Stats) {
+	st.RestartBlocks += g.stBlocks
+	g.stBlocks = 0
+}
@@ -4,9 +4,12 @@
 package xo
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"math/rand"
 	"runtime"
 	"sync"
 	"time"
@@ -25,6 +28,12 @@ const (
 	CancelTicks   int64 = 1
 )
 
+const (
+	modeConflicts   uint  = 2000
+	rephaseInterval uint  = 1000
+	rephaseSeed     int64 = 1
+)
+
 // Solver implements a CDCL like solver with
 // some performance bells and whistles
 type S struct {
@@ -34,10 +43,18 @@ type S struct {
 	Guess  *Guess
 	Driver *Deriver
 	Active *Active
+	elim   *elim
+	xors   *xors
+	cards  *cards
+	groups *groups
 	gmu    sync.Mutex
 	rmu    sync.Mutex
 	luby   *Luby
 
+	// clauses queued by Import
+	imu     sync.Mutex
+	imports [][]z.Lit
+
 	// last conflict clause
 	x z.C
 	// if trivially inconsistent assumptions, first conflicting assumption
@@ -49,24 +66,52 @@ type S struct {
 	// assumptionLevel can be > endTestLevel for untested assumptions
 	assumptLevel int
 	assumes      []z.Lit // only last set of requested assumptions before solve/test.
+	assumed      []z.Lit // assumptions made by the last solve/test.
 	failed       []z.Lit
 	phases       phases
+	opts         *Options
+
+	// proof output, if any
+	proof *proof
 
 	// Control
 	control          *Ctl
 	restartStopwatch int
+	glu              *gluRestarts // nil unless glucose restarts are used
+	modeStopwatch    int64        // conflicts until the next mode switch
+	modeLen          int64
+	rephaseStopwatch int64 // conflicts until the next rephase
+	rephases         int64
+	rephaseRng       *rand.Rand // used if Guess has no rng
+	vivifyProps      int64      // propagations spent vivifying
+	vivifyLearnt     int        // next learnt to vivify
+	vivifyAdded      int        // next added clause to vivify
+	vivifyLits       []z.Lit
 	startTime        time.Time
 	deadline         time.Time // synchronous (no pause)
+	conflictLimit    int64     // Driver.Conflicts at which to stop, or 0
+	propLimit        int64     // Trail.Props at which to stop, or 0
 
 	// Stats (each object has its own, read by ReadStats())
-	stRestarts  int64
-	stSat       int64
-	stUnsat     int64
-	stEnded     int64
-	stPinned    int
-	stIncPinned int
-	stAssumes   int64
-	stFailed    int64
+	stRestarts      int64
+	stSwitches      int64
+	stRephases      int64
+	stWalkFlips     int64
+	stImported      int64
+	stDropped       int64
+	stVivifyChecked int64
+	stVivified      int64
+	stVivifiedLits  int64
+	stProbed        int64
+	stFailedLits    int64
+	stChrono        int64
+	stSat           int64
+	stUnsat         int64
+	stEnded         int64
+	stPinned        int
+	stIncPinned     int
+	stAssumes       int64
+	stFailed        int64
 }
 
 // NewS creates a new Solver with default (relatively small) capacity
@@ -110,6 +155,7 @@ func NewSCdb(cdb *Cdb) *S {
 		Trail:  trail,
 		Guess:  guess,
 		Driver: drv,
+		elim:   newElim(int(vars.Top)),
 		luby:   NewLuby(),
 		x:      CNull,
 		xLit:   z.LitNull,
@@ -120,6 +166,7 @@ func NewSCdb(cdb *Cdb) *S {
 		assumes:      make([]z.Lit, 0, 1024),
 		failed:       make([]z.Lit, 0, 3),
 
+		opts:             DefaultOptions(),
 		restartStopwatch: 0,
 		control:          NewCtl(nil)}
 	s.control.stFunc = func(st *Stats) *Stats {
@@ -127,6 +174,7 @@ func NewSCdb(cdb *Cdb) *S {
 		return st
 	}
 	s.control.xo = s
+	s.Trail.chrono = s.opts.Chrono
 	s.startTime = time.Now()
 	s.deadline = s.startTime
 	return s
@@ -145,7 +193,14 @@ func (s *S) Copy() *S {
 		other.Active = s.Active.Copy()
 		other.Cdb.Active = other.Active
 	}
+	other.elim = s.elim.Copy()
+	other.xors = s.xors.Copy()
+	other.Trail.xors = other.xors
+	other.cards = s.cards.Copy()
+	other.groups = s.groups.Copy()
+	other.Trail.cards = other.cards
 	other.phases = s.phases
+	other.opts = s.opts.withDefaults()
 	luby := NewLuby()
 	*luby = *(s.luby)
 	other.luby = luby
@@ -160,6 +215,20 @@ func (s *S) Copy() *S {
 	other.failed = make([]z.Lit, len(s.failed), cap(s.failed))
 	copy(other.failed, s.failed)
 	other.restartStopwatch = s.restartStopwatch
+	other.modeStopwatch = s.modeStopwatch
+	other.modeLen = s.modeLen
+	other.rephaseStopwatch = s.rephaseStopwatch
+	other.rephases = s.rephases
+	other.vivifyProps = s.vivifyProps
+	other.vivifyLearnt = s.vivifyLearnt
+	other.vivifyAdded = s.vivifyAdded
+	if s.rephaseRng != nil {
+		other.rephaseRng = rand.New(rand.NewSource(s.rephaseRng.Int63()))
+	}
+	if s.glu != nil {
+		glu := *s.glu
+		other.glu = &glu
+	}
 	other.control = NewCtl(other)
 	other.control.stFunc = func(st *Stats) *Stats {
 		other.ReadStats(st)
@@ -198,6 +267,48 @@ func (s *S) Try(dur time.Duration) int {
 	return s.Solve()
 }
 
+// SolveContext solves until ctx is done, returning 0 if ctx is done
+// before a result is found.  Like Solve, SolveContext consumes the
+// untested assumptions.
+func (s *S) SolveContext(ctx context.Context) int {
+	if ctx.Err() != nil {
+		s.assumes = s.assumes[:0]
+		return 0
+	}
+	s.control.ctx = ctx
+	defer func() {
+		s.control.ctx = nil
+	}()
+	return s.Solve()
+}
+
+// TryBudget solves like Try, but stops after the given number of conflicts
+// or propagations rather than after a duration, so that its result does
+// not depend on timing.  A budget which is 0 or less is not limited.
+// TryBudget returns 0 if a budget is exhausted.
+func (s *S) TryBudget(conflicts, props int64) int {
+	if conflicts > 0 {
+		s.conflictLimit = s.Driver.Conflicts + conflicts
+	}
+	if props > 0 {
+		s.propLimit = s.Trail.Props + props
+	}
+	defer func() {
+		s.conflictLimit = 0
+		s.propLimit = 0
+	}()
+	return s.Solve()
+}
+
+// budgetEnded returns whether the budget given to TryBudget, if any, is
+// exhausted.
+func (s *S) budgetEnded() bool {
+	if s.conflictLimit != 0 && s.Driver.Conflicts >= s.conflictLimit {
+		return true
+	}
+	return s.propLimit != 0 && s.Trail.Props >= s.propLimit
+}
+
 // Method Solve solves the problem added to the solver under
 // assumptions specified by Assume.
 //
@@ -208,6 +319,7 @@ func (s *S) Solve() int {
 	defer func() {
 		s.assumptLevel = 0
 		s.assumes = s.assumes[:0]
+		s.flushProof()
 	}()
 	trail := s.Trail
 	if r := s.solveInit(); r != 0 {
@@ -220,24 +332,45 @@ func (s *S) Solve() int {
 	cdb := s.Cdb
 	aLevel := s.assumptLevel
 	var x z.C
-	nxtTick := trail.Props + PropTick
+	propTick := s.opts.PropTick
+	nxtTick := trail.Props + propTick
 	tick := int64(0)
 
 	for {
 		x = trail.Prop()
 		if x != CNull {
 			// conflict
+			if s.opts.Chrono || s.xors != nil || s.cards != nil {
+				// the conflict may be false at a lower level.
+				if x = trail.backToConflict(x, aLevel); x == CNull {
+					continue
+				}
+			}
 			if trail.Level <= aLevel {
 				s.x = x
+				if trail.Level == 0 {
+					s.rootConflict(x)
+				}
 				s.stUnsat++
 				return -1
 			}
+			if s.opts.TargetPhases || len(s.opts.Rephases) > 0 {
+				guess.updatePhases(s.conflictFree())
+				s.rephaseStopwatch--
+			}
 			drvd := driver.Derive(x)
-			if drvd.TargetLevel < aLevel {
-				trail.Back(aLevel)
-			} else {
-				trail.Back(drvd.TargetLevel)
+			if s.glu != nil {
+				s.glu.conflict(drvd.Lbd, trail.Tail)
+			}
+			lvl := drvd.TargetLevel
+			if lvl < aLevel {
+				lvl = aLevel
 			}
+			if s.opts.Chrono && trail.Level-lvl > int(s.opts.ChronoLevels) {
+				lvl = trail.Level - 1
+				s.stChrono++
+			}
+			trail.Back(lvl)
 			trail.Assign(drvd.Unit, drvd.P)
 			guess.Decay()
 			cdb.Decay()
@@ -247,12 +380,28 @@ func (s *S) Solve() int {
 				s.stIncPinned = trail.Tail
 			}
 			s.restartStopwatch--
+			if s.opts.Mode == ModeSwitch {
+				s.modeStopwatch--
+				if s.modeStopwatch <= 0 {
+					s.switchMode()
+				}
+			}
+			if s.budgetEnded() {
+				s.stEnded++
+				trail.Back(s.endTestLevel)
+				return 0
+			}
 			continue
 		}
+		if s.budgetEnded() {
+			s.stEnded++
+			trail.Back(s.endTestLevel)
+			return 0
+		}
 
 		// propagation ticker
 		if trail.Props > nxtTick {
-			nxtTick += PropTick
+			nxtTick += propTick
 			tick++
 			if tick%CancelTicks == 0 {
 				if s.deadline != s.startTime && time.Until(s.deadline) <= 0 {
@@ -267,10 +416,37 @@ func (s *S) Solve() int {
 		}
 
 		// maybe restart.
-		if s.restartStopwatch <= 0 {
-			nxt := s.luby.Next()
-			s.restartStopwatch = int(nxt * RestartFactor)
+		if s.restartStopwatch <= 0 || s.gluReady() {
+			s.restartStopwatch = s.restartInterval()
+			if s.glu != nil {
+				s.glu.restart()
+			}
 			trail.Back(s.assumptLevel)
+			if trail.Level == 0 {
+				if x := s.addImports(); x != CNull {
+					s.x = x
+					s.rootConflict(x)
+					s.stUnsat++
+					return -1
+				}
+			} else if s.endTestLevel == 0 && s.importReady() {
+				if s.importUnder() == -1 {
+					s.stUnsat++
+					return -1
+				}
+				aLevel = s.assumptLevel
+			}
+			if len(s.opts.Rephases) > 0 && s.rephaseStopwatch <= 0 {
+				s.rephase()
+			}
+			if s.vivifyReady() {
+				if x := s.vivify(); x != CNull {
+					s.x = x
+					s.rootConflict(x)
+					s.stUnsat++
+					return -1
+				}
+			}
 			s.stRestarts++
 			guess.nextRestart(s.restartStopwatch)
 		}
@@ -291,6 +467,7 @@ func (s *S) Solve() int {
 
 				log.Fatalf("%p %p internal error in solve: sat model\n", s, s.control)
 			}
+			s.elim.extend(vars.Vals)
 			s.stSat++
 			//fmt.Printf("vars %s\n", s.Vars)
 			return 1
@@ -306,7 +483,7 @@ func (s *S) Solve() int {
 
 // Value retrieves the value of the literal m
 func (s *S) Value(m z.Lit) bool {
-	return s.Vars.Vals[m] == 1
+	return s.elim.value(m, s.Vars.Vals) == 1
 }
 
 // Test checks if the solver is consistent under unit propagation
@@ -353,6 +530,10 @@ func (s *S) Test(ms []z.Lit) (res int, ns []z.Lit) {
 	if x := s.cleanupSolve(); x != CNull {
 		panic("test after unresolved unsat.")
 	}
+	if len(s.testLevels) == 0 && len(s.elim.stack) != 0 {
+		// eliminated variables may be used under the scope.
+		s.restoreAll()
+	}
 	res = 0
 	s.testLevels = append(s.testLevels, s.Trail.Level)
 
@@ -378,6 +559,7 @@ func (s *S) Test(ms []z.Lit) (res int, ns []z.Lit) {
 			}
 			log.Fatal("internal error test: sat model")
 		}
+		s.elim.extend(s.Vars.Vals)
 		s.stSat++
 		return 1, ns
 	}
@@ -425,6 +607,9 @@ func (s *S) Untest() int {
 	s.Trail.backWithLates(lastTestLevel)
 	if x := trail.Prop(); x != CNull {
 		s.x = x
+		if lvl, _, _ := trail.conflictLevel(x); lvl == 0 {
+			s.rootConflict(x)
+		}
 		return -1
 	}
 	s.x = CNull
@@ -473,6 +658,31 @@ func (s *S) ReadStats(st *Stats) {
 	defer s.rmu.Unlock()
 	st.Restarts += s.stRestarts
 	s.stRestarts = 0
+	st.ModeSwitches += s.stSwitches
+	s.stSwitches = 0
+	st.Rephases += s.stRephases
+	s.stRephases = 0
+	st.WalkFlips += s.stWalkFlips
+	s.stWalkFlips = 0
+	st.Imported += s.stImported
+	s.stImported = 0
+	st.Dropped += s.stDropped
+	s.stDropped = 0
+	st.VivifyChecked += s.stVivifyChecked
+	s.stVivifyChecked = 0
+	st.Vivified += s.stVivified
+	s.stVivified = 0
+	st.VivifiedLits += s.stVivifiedLits
+	s.stVivifiedLits = 0
+	st.Probed += s.stProbed
+	s.stProbed = 0
+	st.FailedLits += s.stFailedLits
+	s.stFailedLits = 0
+	st.ChronoBacks += s.stChrono
+	s.stChrono = 0
+	if s.glu != nil {
+		s.glu.readStats(st)
+	}
 	st.Sat += s.stSat
 	s.stSat = 0
 	st.Unsat += s.stUnsat
@@ -489,6 +699,13 @@ func (s *S) ReadStats(st *Stats) {
 	s.Trail.readStats(st)
 	s.Guess.readStats(st)
 	s.Driver.readStats(st)
+	s.elim.readStats(st)
+	if s.xors != nil {
+		s.xors.readStats(st)
+	}
+	if s.cards != nil {
+		s.cards.readStats(st)
+	}
 	s.Cdb.readStats(st)
 }
 
@@ -498,6 +715,12 @@ func (s *S) Add(m z.Lit) {
 	if m == z.LitNull {
 		s.ensure0()
 		s.Cdb.checkModel = true
+		s.restoreLits(s.Cdb.AddLits)
+		s.elim.dirty = true
+		if s.groups != nil && s.groups.open {
+			s.addGroupClause()
+			return
+		}
 	}
 	loc, u := s.Cdb.Add(m)
 	if u != z.LitNull {
@@ -583,7 +806,27 @@ func (s *S) MaxVar() z.Var {
 // which together caused previous call to be unsat.
 //
 // If previous call was not unsat, then Why() returns ms
+//
+// Activation literals of groups, see AddGroup, are not included.
 func (s *S) Why(ms []z.Lit) []z.Lit {
+	n := len(ms)
+	ms = s.why(ms)
+	if s.groups == nil {
+		return ms
+	}
+	ids := s.groups.ids
+	j := n
+	for _, m := range ms[n:] {
+		if _, ok := ids[m.Var()]; ok {
+			continue
+		}
+		ms[j] = m
+		j++
+	}
+	return ms[:j]
+}
+
+func (s *S) why(ms []z.Lit) []z.Lit {
 	s.failed = ms
 	if s.xLit != z.LitNull {
 		s.failed = append(s.failed, s.xLit)
@@ -596,6 +839,67 @@ func (s *S) Why(ms []z.Lit) []z.Lit {
 	return s.failed
 }
 
+// restartInterval returns the number of conflicts until the next restart.
+func (s *S) restartInterval() int {
+	if s.Guess.focused {
+		return math.MaxInt32
+	}
+	switch s.opts.Restarts {
+	case RestartNone, RestartGlucose:
+		return math.MaxInt32
+	default:
+		return int(s.luby.Next() * s.opts.RestartFactor)
+	}
+}
+
+// SetOptions sets the tuning options of s.  Zero valued fields of opts
+// take their default values.  SetOptions should not be called while s is
+// solving.
+func (s *S) SetOptions(opts *Options) {
+	s.lock()
+	defer s.unlock()
+	s.opts = opts.withDefaults()
+	s.Guess.setOptions(s.opts)
+	s.Cdb.gc.setOptions(s.opts)
+	s.glu = nil
+	if s.opts.Restarts == RestartGlucose || s.opts.Mode != ModeStable {
+		s.glu = newGluRestarts(s.opts)
+	}
+	s.Guess.setFocused(s.opts.Mode != ModeStable)
+	s.modeLen = int64(s.opts.ModeConflicts)
+	s.modeStopwatch = s.modeLen
+	s.Guess.useTarget = s.opts.TargetPhases
+	s.Trail.chrono = s.opts.Chrono
+	s.rephases = 0
+	s.rephaseStopwatch = int64(s.opts.RephaseInterval)
+	s.phases = 0
+}
+
+// gluReady returns whether a glucose style restart should occur.
+func (s *S) gluReady() bool {
+	if s.glu == nil {
+		return false
+	}
+	if !s.Guess.focused && s.opts.Restarts != RestartGlucose {
+		return false
+	}
+	return s.glu.ready()
+}
+
+// switchMode switches between focused and stable mode, forcing a restart.
+func (s *S) switchMode() {
+	s.Guess.setFocused(!s.Guess.focused)
+	s.modeLen *= 2
+	s.modeStopwatch = s.modeLen
+	s.restartStopwatch = 0
+	s.stSwitches++
+}
+
+// Options returns a copy of the options of s.
+func (s *S) Options() *Options {
+	return s.opts.withDefaults()
+}
+
 // returns -1 if known to be inconsistent by BCP
 // 0 otherwise.
 func (s *S) solveInit() int {
@@ -603,16 +907,26 @@ func (s *S) solveInit() int {
 	// set up restarts TODO(wsc) optimize this
 	s.luby = NewLuby()
 	for {
-		r := s.luby.Next() * RestartFactor
-		if r >= RestartAfter {
-			s.restartStopwatch = int(r)
+		r := s.restartInterval()
+		if r >= int(s.opts.RestartAfter) {
+			s.restartStopwatch = r
 			break
 		}
 	}
+	if s.glu != nil {
+		s.glu.restart()
+	}
 	if x := s.cleanupSolve(); x != CNull {
 		s.x = x
 		return -1
 	}
+	if s.Trail.Level == 0 {
+		if x := s.addImports(); x != CNull {
+			s.x = x
+			s.rootConflict(x)
+			return -1
+		}
+	}
 
 	//log.Printf("%s\n", s.Trail)
 	//log.Printf("%s\n", s.Vars)
@@ -643,6 +957,21 @@ func (s *S) cleanupSolve() z.C {
 			s.x = CNull
 			break
 		}
+		lvl, _, _ := trail.conflictLevel(s.x)
+		if lvl == 0 {
+			s.rootConflict(s.x)
+			s.x = CNull
+			break
+		}
+		if lvl < trail.Level {
+			// s.x is false at a lower level under chronological
+			// backtracking.
+			if lvl < s.endTestLevel {
+				trail.Back(s.endTestLevel)
+				return s.x
+			}
+			trail.Back(lvl)
+		}
 		drvd := s.Driver.Derive(s.x)
 		if drvd.TargetLevel < s.endTestLevel {
 			trail.Back(s.endTestLevel)
@@ -680,17 +1009,52 @@ func (s *S) makeAssumptions() int {
 	defer func() {
 		s.assumes = s.assumes[:0]
 	}()
+	if trail.Level == 0 {
+		s.groupAssumes()
+	}
+	for _, m := range s.assumes {
+		if s.elim.elimd[m.Var()] {
+			s.restore(m.Var())
+		}
+	}
 	// check if consistent without assumptions
 	if s.Cdb.Bot != CNull {
 		s.x = s.Cdb.Bot
+		s.rootConflict(s.x)
 		return -1
 	}
 	if x := trail.Prop(); x != CNull {
 		s.x = x
+		if lvl, _, _ := trail.conflictLevel(x); lvl == 0 {
+			s.rootConflict(x)
+		}
 		return -1
 	}
+	if s.simplifyReady() {
+		if x := s.simplify(); x != CNull {
+			s.x = x
+			s.rootConflict(x)
+			return -1
+		}
+	}
+	if s.vivifyReady() {
+		if x := s.vivify(); x != CNull {
+			s.x = x
+			s.rootConflict(x)
+			return -1
+		}
+	}
+	s.assumed = append(s.assumed[:0], s.assumes...)
+	return s.assume(s.assumes)
+}
+
+// assume assigns the assumptions ms which are not yet true, each at a new
+// decision level, returning -1 if they are inconsistent under unit
+// propagation and 0 otherwise.
+func (s *S) assume(ms []z.Lit) int {
+	trail := s.Trail
 	vals := s.Vars.Vals
-	for _, m := range s.assumes {
+	for _, m := range ms {
 		switch vals[m] {
 		case 0:
 			s.assumptLevel++
@@ -713,6 +1077,56 @@ func (s *S) makeAssumptions() int {
 	return 0
 }
 
+// rootConflict records that x is false at decision level 0, so that
+// the problem is unsat independent of any assumptions.
+func (s *S) rootConflict(x z.C) {
+	s.Cdb.Bot = x
+	if s.proof != nil && s.proof.done {
+		return
+	}
+	if s.Cdb.Tracer != nil {
+		s.Cdb.Tracer.Add(CNull, nil, s.Cdb.rootHints(x))
+	}
+}
+
+// SetProof causes s to write a proof of unsatisfiability in format f to
+// w.  The proof covers clauses added after the call to SetProof, and so
+// SetProof should be called before any clauses are added.  If w is nil,
+// then proof output is disabled.
+//
+// Proof output is buffered and flushed at the end of each call to Solve.
+// LRAT proofs number clauses in the order they are added, and so all
+// clauses must be added before the proof is first flushed.
+func (s *S) SetProof(w io.Writer, f ProofFormat) {
+	s.flushProof()
+	if w == nil {
+		s.proof = nil
+		s.Cdb.SetTracer(nil)
+		return
+	}
+	s.proof = newProof(w, f)
+	if f.IsLrat() {
+		s.Cdb.SetHintTracer(s.proof)
+		return
+	}
+	s.Cdb.SetTracer(s.proof)
+}
+
+// ProofError returns the first error which occured writing the proof, if
+// any.
+func (s *S) ProofError() error {
+	if s.proof == nil {
+		return nil
+	}
+	return s.proof.flush()
+}
+
+func (s *S) flushProof() {
+	if s.proof != nil {
+		s.proof.flush()
+	}
+}
+
 func (s *S) final(ms []z.Lit) {
 	marks := make([]bool, s.Vars.Max+1)
 	for _, m := range ms {
@@ -771,6 +1185,13 @@ func (s *S) ensureLitCap(m z.Lit) {
 		s.Trail.growToVar(top)
 		s.Guess.growToVar(top)
 		s.Driver.growToVar(top)
+		s.elim.growToVar(top)
+		if s.xors != nil {
+			s.xors.growToVar(top)
+		}
+		if s.cards != nil {
+			s.cards.growToVar(top)
+		}
 		if s.Active != nil {
 			s.Active.growToVar(top)
 		}
@@ -110,6 +110,52 @@ func TestSTimeout(t *testing.T) {
 	//log.Printf("%d props (%s)\n", s.SolveStats.Props, s.Driver)
 }
 
+func TestSTryBudget(t *testing.T) {
+	// solves php in steps of 100 conflicts, twice, which must take the
+	// same steps.
+	steps := func() (int, int64) {
+		s := NewS()
+		gen.Php(s, 7, 6)
+		st := NewStats()
+		n := 0
+		for {
+			r := s.TryBudget(100, 0)
+			s.ReadStats(st)
+			if r == -1 {
+				return n, st.Conflicts
+			}
+			if r != 0 {
+				t.Fatalf("php sat")
+			}
+			n++
+			if st.Conflicts != int64(100*n) {
+				t.Fatalf("conflicts after %d budgets: %d", n, st.Conflicts)
+			}
+		}
+	}
+	n, c := steps()
+	if n == 0 {
+		t.Fatalf("php solved within budget")
+	}
+	if m, d := steps(); m != n || d != c {
+		t.Errorf("budgets not reproducible: %d/%d vs %d/%d", m, d, n, c)
+	}
+
+	s := NewS()
+	gen.Php(s, 7, 6)
+	if s.TryBudget(0, 1000) != 0 {
+		t.Fatalf("php solved within props budget")
+	}
+	st := NewStats()
+	s.ReadStats(st)
+	if st.Props < 1000 || st.Props > 2000 {
+		t.Errorf("props: %d", st.Props)
+	}
+	if s.Solve() != -1 {
+		t.Errorf("php not unsat after budget")
+	}
+}
+
 // test until conflict, go back
 func TestSTest(t *testing.T) {
 	N := 100
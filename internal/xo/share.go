@@ -0,0 +1,126 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/z"
+)
+
+// SetExport causes s to call f with each learnt clause ms which has at most
+// maxLen literals and an lbd of at most maxLbd, where the lbd of ms is the
+// number of distinct decision levels of the literals of ms other than the
+// first when ms is learnt.  f is called in the goroutine solving s, and
+// must neither retain nor modify ms.  If f is nil, then export is
+// disabled.
+//
+// Copies of s do not export learnt clauses.
+func (s *S) SetExport(maxLen, maxLbd int, f func(ms []z.Lit, lbd int)) {
+	s.Cdb.export = f
+	s.Cdb.exportLen = maxLen
+	s.Cdb.exportLbd = maxLbd
+}
+
+// Import queues the clause ms to be added to s as a learnt clause.  ms
+// should be implied by the constraints added to s, such as a clause
+// exported from a copy of s.
+//
+// Import may be called from any goroutine, also while s is solving.
+// Queued clauses are added at the start of a call to Solve and at
+// restarts, outside of test scopes.  At restarts under assumptions, s
+// backtracks to add them and then makes the assumptions again.  Clauses
+// with variables above the maximum variable of s or eliminated from s are
+// dropped, as are all clauses if s writes a proof, and counted in the
+// Dropped stat.
+func (s *S) Import(ms []z.Lit) {
+	ns := make([]z.Lit, len(ms))
+	copy(ns, ms)
+	s.imu.Lock()
+	defer s.imu.Unlock()
+	s.imports = append(s.imports, ns)
+}
+
+// addImports adds the queued clauses at decision level 0 and propagates,
+// returning a conflict if one is found.
+func (s *S) addImports() z.C {
+	s.imu.Lock()
+	cls := s.imports
+	s.imports = nil
+	s.imu.Unlock()
+	if len(cls) == 0 {
+		return CNull
+	}
+	if s.Cdb.Tracer != nil {
+		// imported clauses are not derived in the proof.
+		s.stDropped += int64(len(cls))
+		return CNull
+	}
+	trail := s.Trail
+	vals := s.Vars.Vals
+	elimd := s.elim.elimd
+	cdb := s.Cdb
+outer:
+	for _, ms := range cls {
+		// complementary and duplicate literals are adjacent when sorted.
+		sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+		ns := ms[:0]
+		for i, m := range ms {
+			v := m.Var()
+			if v > s.Vars.Max || elimd[v] {
+				s.stDropped++
+				continue outer
+			}
+			if vals[m] == 1 {
+				continue outer
+			}
+			if i > 0 && m.Var() == ms[i-1].Var() {
+				if m != ms[i-1] {
+					continue outer
+				}
+				continue
+			}
+			if vals[m] == 0 {
+				ns = append(ns, m)
+			}
+		}
+		s.stImported++
+		switch len(ns) {
+		case 0:
+			return cdb.addLearnt(nil, 0, nil)
+		case 1:
+			trail.Assign(ns[0], cdb.addLearnt(ns, 0, nil))
+		default:
+			cdb.addLearnt(ns, len(ns)-1, nil)
+		}
+	}
+	return trail.Prop()
+}
+
+// importReady returns whether clauses are queued to be added.
+func (s *S) importReady() bool {
+	if s.Cdb.Tracer != nil {
+		return false
+	}
+	s.imu.Lock()
+	defer s.imu.Unlock()
+	return len(s.imports) != 0
+}
+
+// importUnder adds the queued clauses at a restart under the assumptions
+// of a solve without tests, by backtracking to level 0 and then making the
+// assumptions again.  It returns -1 if the result is unsat, as recorded by
+// makeAssumptions, and 0 otherwise.
+func (s *S) importUnder() int {
+	trail := s.Trail
+	trail.Back(0)
+	s.assumptLevel = 0
+	if x := s.addImports(); x != CNull {
+		s.x = x
+		s.rootConflict(x)
+		return -1
+	}
+	s.stPinned = trail.Tail
+	return s.assume(s.assumed)
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/z"
+)
+
+func TestExport(t *testing.T) {
+	s := NewS()
+	gen.Php(s, 7, 6)
+	o := s.Copy()
+	var cls [][]z.Lit
+	s.SetExport(3, 2, func(ms []z.Lit, lbd int) {
+		if len(ms) > 3 || lbd > 2 {
+			t.Fatalf("exported %v with lbd %d", ms, lbd)
+		}
+		cls = append(cls, append([]z.Lit(nil), ms...))
+	})
+	if s.Solve() != -1 {
+		t.Fatalf("php sat")
+	}
+	if len(cls) == 0 {
+		t.Fatalf("nothing exported")
+	}
+	st := NewStats()
+	s.ReadStats(st)
+	if st.Exported != int64(len(cls)) {
+		t.Errorf("exported %d/%d", st.Exported, len(cls))
+	}
+	for _, ms := range cls {
+		for _, m := range ms {
+			o.Assume(m.Not())
+		}
+		if o.Solve() != -1 {
+			t.Errorf("exported %v not implied", ms)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	st := NewStats()
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 100, 400+rng.Intn(40))
+		s := NewS()
+		if i%2 == 1 {
+			s.SetOptions(&Options{Eliminate: true})
+		}
+		addCnf(s, cnf)
+		o := s.Copy()
+		s.SetExport(8, 4, func(ms []z.Lit, lbd int) {
+			o.Import(ms)
+		})
+		r := s.Solve()
+		if o.Solve() != r {
+			t.Fatalf("result with imports %d", r)
+		}
+		if r == 1 {
+			checkCnf(t, o, cnf)
+		}
+		o.ReadStats(st)
+	}
+	if st.Imported == 0 {
+		t.Errorf("nothing imported")
+	}
+}
+
+func TestImportAssumed(t *testing.T) {
+	rng := rand.New(rand.NewSource(43))
+	st := NewStats()
+	for i := 0; i < 4; i++ {
+		cnf := elimCnf(rng, 200, 820+rng.Intn(40))
+		s := NewS()
+		addCnf(s, cnf)
+		o := s.Copy()
+		// s imports its own learnt clauses, which are only queued
+		// while solving under assumptions.
+		s.SetExport(8, 4, func(ms []z.Lit, lbd int) {
+			s.Import(ms)
+		})
+		a := z.Var(rng.Intn(200) + 1).Pos()
+		s.Assume(a)
+		o.Assume(a)
+		r := s.Solve()
+		if o.Solve() != r {
+			t.Fatalf("result with imports %d", r)
+		}
+		switch r {
+		case 1:
+			checkCnf(t, s, cnf)
+			if !s.Value(a) {
+				t.Errorf("assumption false")
+			}
+		case -1:
+			if why := s.Why(nil); len(why) > 1 || len(why) == 1 && why[0] != a {
+				t.Errorf("why %v", why)
+			}
+		}
+		s.ReadStats(st)
+	}
+	if st.Imported == 0 {
+		t.Errorf("nothing imported under assumptions")
+	}
+}
+
+func TestImportSolving(t *testing.T) {
+	// a contradiction imported into a running solve ends it.
+	s := NewS()
+	gen.Php(s, 11, 10)
+	c := s.GoSolve()
+	time.Sleep(10 * time.Millisecond)
+	m := z.Var(1).Pos()
+	s.Import([]z.Lit{m})
+	s.Import([]z.Lit{m.Not()})
+	if r := c.Try(5 * time.Second); r != -1 {
+		t.Errorf("result %d after importing a contradiction", r)
+	}
+}
@@ -27,9 +27,36 @@ type Stats struct {
 	GuessRescales int64
 	Conflicts     int64
 	Learnts       int
+	CoreLearnts   int
+	Tier2Learnts  int
+	LocalLearnts  int
 	LearntLits    int64
 	MinLits       int64
+	ChronoBacks   int64
 	Restarts      int64
+	RestartBlocks int64
+	ModeSwitches  int64
+	Rephases      int64
+	WalkFlips     int64
+	VivifyChecked int64
+	Vivified      int64
+	VivifiedLits  int64
+	Eliminated    int64
+	Subsumed      int64
+	Strengthened  int64
+	Substituted   int64
+	Probed        int64
+	FailedLits    int64
+	Xors          int64
+	XorProps      int64
+	XorConflicts  int64
+	XorPivots     int64
+	Cards         int64
+	CardProps     int64
+	CardConflicts int64
+	Exported      int64
+	Imported      int64
+	Dropped       int64
 	Compactions   int64
 	Removed       int64
 	RemovedLits   int64
@@ -61,9 +88,36 @@ c guesses:                            %16d
 c guessrescales:                      %16d
 c conflicts:                          %16d
 c learnts:                            %16d
+c corelearnts:                        %16d
+c tier2learnts:                       %16d
+c locallearnts:                       %16d
 c learntlits:                         %16d
 c minLits:                            %16d
+c chronobacks:                        %16d
 c restarts:                           %16d
+c restartblocks:                      %16d
+c modeswitches:                       %16d
+c rephases:                           %16d
+c walkflips:                          %16d
+c vivifychecked:                      %16d
+c vivified:                           %16d
+c vivifiedlits:                       %16d
+c eliminated:                         %16d
+c subsumed:                           %16d
+c strengthened:                       %16d
+c substituted:                        %16d
+c probed:                             %16d
+c failedlits:                         %16d
+c xors:                               %16d
+c xorprops:                           %16d
+c xorconflicts:                       %16d
+c xorpivots:                          %16d
+c cards:                              %16d
+c cardprops:                          %16d
+c cardconflicts:                      %16d
+c exported:                           %16d
+c imported:                           %16d
+c dropped:                            %16d
 c compactions:                        %16d
 c removed:                            %16d
 c removedlits:                        %16d
@@ -74,8 +128,13 @@ c pinned:                             %16d
 c incpinned:                          %16d`,
 		s.Dur, s.Vars, s.Props, s.Added, s.AddedLits, s.AddedUnits, s.AddedBinary, s.AddedTernary,
 		s.AddedBig, s.Sat, s.Unsat, s.Ended, s.Assumptions, s.Failed,
-		s.Guesses, s.GuessRescales, s.Conflicts, s.Learnts, s.LearntLits,
-		s.MinLits, s.Restarts, s.Compactions, s.Removed, s.RemovedLits, s.CDatGcs,
+		s.Guesses, s.GuessRescales, s.Conflicts, s.Learnts,
+		s.CoreLearnts, s.Tier2Learnts, s.LocalLearnts, s.LearntLits,
+		s.MinLits, s.ChronoBacks, s.Restarts, s.RestartBlocks, s.ModeSwitches, s.Rephases, s.WalkFlips,
+		s.VivifyChecked, s.Vivified, s.VivifiedLits,
+		s.Eliminated, s.Subsumed, s.Strengthened, s.Substituted, s.Probed, s.FailedLits,
+		s.Xors, s.XorProps, s.XorConflicts, s.XorPivots,
+		s.Cards, s.CardProps, s.CardConflicts, s.Exported, s.Imported, s.Dropped, s.Compactions, s.Removed, s.RemovedLits, s.CDatGcs,
 		s.CHeatRescales, s.MaxTrail, s.Pinned, s.IncPinned)
 }
 
@@ -105,9 +164,36 @@ func (s *Stats) Reset() {
 	s.GuessRescales = 0
 	s.Conflicts = 0
 	s.Learnts = 0
+	s.CoreLearnts = 0
+	s.Tier2Learnts = 0
+	s.LocalLearnts = 0
 	s.LearntLits = 0
 	s.MinLits = 0
+	s.ChronoBacks = 0
 	s.Restarts = 0
+	s.RestartBlocks = 0
+	s.ModeSwitches = 0
+	s.Rephases = 0
+	s.WalkFlips = 0
+	s.VivifyChecked = 0
+	s.Vivified = 0
+	s.VivifiedLits = 0
+	s.Eliminated = 0
+	s.Subsumed = 0
+	s.Strengthened = 0
+	s.Substituted = 0
+	s.Probed = 0
+	s.FailedLits = 0
+	s.Xors = 0
+	s.XorProps = 0
+	s.XorConflicts = 0
+	s.XorPivots = 0
+	s.Cards = 0
+	s.CardProps = 0
+	s.CardConflicts = 0
+	s.Exported = 0
+	s.Imported = 0
+	s.Dropped = 0
 	s.Compactions = 0
 	s.Removed = 0
 	s.RemovedLits = 0
@@ -138,9 +224,36 @@ func (s *Stats) Accumulate(t *Stats) {
 	s.GuessRescales += t.GuessRescales
 	s.Conflicts += t.Conflicts
 	s.Learnts = t.Learnts
+	s.CoreLearnts = t.CoreLearnts
+	s.Tier2Learnts = t.Tier2Learnts
+	s.LocalLearnts = t.LocalLearnts
 	s.LearntLits = t.LearntLits
 	s.MinLits = t.MinLits
+	s.ChronoBacks += t.ChronoBacks
 	s.Restarts += t.Restarts
+	s.RestartBlocks += t.RestartBlocks
+	s.ModeSwitches += t.ModeSwitches
+	s.Rephases += t.Rephases
+	s.WalkFlips += t.WalkFlips
+	s.VivifyChecked += t.VivifyChecked
+	s.Vivified += t.Vivified
+	s.VivifiedLits += t.VivifiedLits
+	s.Eliminated += t.Eliminated
+	s.Subsumed += t.Subsumed
+	s.Strengthened += t.Strengthened
+	s.Substituted += t.Substituted
+	s.Probed += t.Probed
+	s.FailedLits += t.FailedLits
+	s.Xors += t.Xors
+	s.XorProps += t.XorProps
+	s.XorConflicts += t.XorConflicts
+	s.XorPivots += t.XorPivots
+	s.Cards += t.Cards
+	s.CardProps += t.CardProps
+	s.CardConflicts += t.CardConflicts
+	s.Exported += t.Exported
+	s.Imported += t.Imported
+	s.Dropped += t.Dropped
 	s.Compactions += t.Compactions
 	s.Removed += t.Removed
 	s.RemovedLits += t.RemovedLits
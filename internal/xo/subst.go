@@ -0,0 +1,222 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import "github.com/go-air/gini/z"
+
+// substitute replaces literals which are equivalent by binary clauses
+// with a representative of their equivalence class throughout the added
+// clauses, and removes the learnt clauses containing replaced literals.
+//
+// Replaced variables are eliminated in the sense of elim: their models
+// follow from their representatives, and they are restored along with the
+// clauses making them equivalent to their representatives if they are
+// subsequently used.
+//
+// substitute returns CNull, or a conflict at level 0 if a unit resulting
+// from substitution leads to one.
+func (s *S) substitute() z.C {
+	reprs := s.equivalences()
+	if reprs == nil {
+		return CNull
+	}
+	e := s.newElimPass()
+	el := s.elim
+	tracer := s.Cdb.Tracer
+	for v := z.Var(1); v <= s.Vars.Max; v++ {
+		r := reprs[v.Pos()]
+		if r == z.LitNull {
+			continue
+		}
+		ms := []z.Lit{v.Pos(), r.Not(), z.LitNull, v.Neg(), r, z.LitNull}
+		if tracer != nil {
+			// the substitutions follow from these, which stay in
+			// the proof since restoring v adds them back.
+			tracer.Add(CNull, ms[0:2], nil)
+			tracer.Add(CNull, ms[3:5], nil)
+		}
+		el.stack = append(el.stack, elimBlock{v: v, ms: ms})
+		el.elimd[v] = true
+		el.stSubstituted++
+		s.Guess.eliminate(v)
+	}
+	marks := e.marks
+	for i := range e.cls {
+		ms := e.cls[i].ms
+		j := 0
+		for ; j < len(ms); j++ {
+			if reprs[ms[j]] != z.LitNull {
+				break
+			}
+		}
+		if j == len(ms) {
+			continue
+		}
+		e.kill(i)
+		ns := make([]z.Lit, 0, len(ms))
+		taut := false
+		for _, m := range ms {
+			if r := reprs[m]; r != z.LitNull {
+				m = r
+			}
+			switch marks[m.Var()] {
+			case 0:
+				marks[m.Var()] = m.Sign()
+				ns = append(ns, m)
+			case -m.Sign():
+				taut = true
+			}
+		}
+		for _, m := range ns {
+			marks[m.Var()] = 0
+		}
+		if !taut {
+			e.derive(ns)
+		}
+	}
+	if x := e.commit(); x != CNull {
+		return x
+	}
+	return s.Trail.Prop()
+}
+
+// equivalences returns the representatives of literals which are
+// equivalent to other literals by the binary clauses, indexed by literal,
+// with z.LitNull for literals which are not replaced, or nil if there are
+// none.
+//
+// Equivalence classes are the strongly connected components of the binary
+// implication graph.  Representatives are frozen or assumed variables if
+// possible, and otherwise the least variables.  Frozen and assumed
+// variables are not replaced.
+func (s *S) equivalences() []z.Lit {
+	vals := s.Vars.Vals
+	watches := s.Vars.Watches
+	elimd := s.elim.elimd
+	frozen := s.elim.frozen
+	assumed := s.assumedVars()
+	fixed := func(v z.Var) bool {
+		return frozen[v] != 0 || assumed[v]
+	}
+	better := func(m, n z.Lit) bool {
+		if fixed(m.Var()) != fixed(n.Var()) {
+			return fixed(m.Var())
+		}
+		return m.Var() < n.Var()
+	}
+	n := 2 * (int(s.Vars.Max) + 1)
+	index := make([]int, n)
+	low := make([]int, n)
+	onStk := make([]bool, n)
+	done := make([]bool, n)
+	inComp := make([]bool, n)
+	reprs := make([]z.Lit, n)
+	found := false
+	var stk, comp []z.Lit
+	type frame struct {
+		m z.Lit
+		i int
+	}
+	var calls []frame
+	k := 0
+	visit := func(m z.Lit) {
+		k++
+		index[m], low[m] = k, k
+		onStk[m] = true
+		stk = append(stk, m)
+		calls = append(calls, frame{m: m})
+	}
+	for root := z.Lit(2); root < z.Lit(n); root++ {
+		if index[root] != 0 || vals[root] != 0 || elimd[root.Var()] {
+			continue
+		}
+		visit(root)
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			m := f.m
+			// m implies the other literal of each binary clause
+			// containing its negation.
+			ws := watches[m.Not()]
+			next := z.LitNull
+			for f.i < len(ws) {
+				w := ws[f.i]
+				f.i++
+				if !w.IsBinary() {
+					continue
+				}
+				o := w.Other()
+				if vals[o] != 0 || elimd[o.Var()] {
+					continue
+				}
+				if index[o] == 0 {
+					next = o
+					break
+				}
+				if onStk[o] && index[o] < low[m] {
+					low[m] = index[o]
+				}
+			}
+			if next != z.LitNull {
+				visit(next)
+				continue
+			}
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				p := calls[len(calls)-1].m
+				if low[m] < low[p] {
+					low[p] = low[m]
+				}
+			}
+			if low[m] != index[m] {
+				continue
+			}
+			comp = comp[:0]
+			for {
+				o := stk[len(stk)-1]
+				stk = stk[:len(stk)-1]
+				onStk[o] = false
+				comp = append(comp, o)
+				if o == m {
+					break
+				}
+			}
+			if len(comp) == 1 || done[m] {
+				continue
+			}
+			for _, o := range comp {
+				inComp[o] = true
+			}
+			rep := comp[0]
+			consistent := true
+			for _, o := range comp {
+				done[o], done[o.Not()] = true, true
+				if inComp[o.Not()] {
+					// o and its negation are equivalent, which
+					// probing finds.
+					consistent = false
+				}
+				if better(o, rep) {
+					rep = o
+				}
+			}
+			for _, o := range comp {
+				inComp[o] = false
+			}
+			if !consistent {
+				continue
+			}
+			for _, o := range comp {
+				if o == rep || fixed(o.Var()) {
+					continue
+				}
+				reprs[o], reprs[o.Not()] = rep, rep.Not()
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	return reprs
+}
@@ -3,8 +3,36 @@
 
 package xo
 
+import "github.com/go-air/gini/z"
+
+// Tracer is notified of the clauses which the solver is given, derives and
+// removes, for example to output a proof of unsatisfiability.
+//
+// Clauses are identified by their location, which changes over time.  When
+// clause locations change, Relocate is called with the relocation map.
 //
+// Tracers should not retain the slices they are passed.
 type Tracer interface {
-	Add(m int)
-	Remove(i int)
+	// Input is called for each clause given to Cdb.Add, in order.  p
+	// is the location of the clause, or CInf if the clause is not stored
+	// as given.
+	Input(p z.C)
+
+	// Add is called with the literals ms of each clause derived by the
+	// solver and stored at location p (CNull if not stored).  The empty
+	// clause is passed as an empty slice.
+	//
+	// hints is nil unless the Cdb is tracing hints.  Otherwise, it
+	// gives in order the locations of clauses which become unit and then
+	// false by unit propagation under the negation of ms.  The location
+	// CInf refers to the last clause passed to Input.
+	Add(p z.C, ms []z.Lit, hints []z.C)
+
+	// Remove is called with the literals of each clause which the
+	// solver removes, before it is relocated to CNull.
+	Remove(p z.C, ms []z.Lit)
+
+	// Relocate is called with the map from old to new locations whenever
+	// clauses move.
+	Relocate(rlm map[z.C]z.C)
 }
@@ -10,6 +10,10 @@ import (
 	"github.com/go-air/gini/z"
 )
 
+// backjumps over more levels than chronoLevels backtrack chronologically
+// when enabled.
+const chronoLevels = 100
+
 type late struct {
 	m z.Lit
 	r z.C
@@ -24,6 +28,17 @@ type Trail struct {
 	Level int
 	D     []z.Lit
 	lates []late
+	keep  []z.Lit
+
+	// xors, if not nil, propagates xor constraints.
+	xors *xors
+	// cards, if not nil, propagates cardinality constraints.
+	cards *cards
+
+	// chrono indicates that implied literals are assigned at the
+	// maximum level of their reasons, which may be lower than Level
+	// under chronological backtracking.
+	chrono bool
 
 	Props   int64
 	MaxTail int
@@ -50,7 +65,9 @@ func (t *Trail) CopyWith(cdb *Cdb, guess *Guess) *Trail {
 		Tail:  t.Tail,
 		Level: t.Level,
 		D:     make([]z.Lit, len(t.D), cap(t.D)),
-		lates: make([]late, len(t.lates), cap(t.lates))}
+		lates: make([]late, len(t.lates), cap(t.lates)),
+
+		chrono: t.chrono}
 	copy(other.D, t.D)
 	copy(other.lates, t.lates)
 	return other
@@ -66,12 +83,20 @@ func (t *Trail) Assign(m z.Lit, c z.C) {
 	if c == CNull {
 		t.Level++
 	}
-	vars.Levels[v] = t.Level
+	if t.chrono && c != CNull {
+		vars.Levels[v] = t.reasonLevel(c)
+	} else {
+		vars.Levels[v] = t.Level
+	}
 	vars.Vals[m] = 1
 	vars.Vals[m.Not()] = -1
 	//log.Printf("assigned %s %s\n", m, c)
 }
 
+// Back backtracks to level trgLevel, unassigning the literals assigned at
+// higher levels.  Literals assigned at trgLevel or lower which occur later
+// in the trail, due to chronological backtracking, are kept and moved down
+// to be propagated again.
 func (t *Trail) Back(trgLevel int) {
 	if t.Level <= trgLevel {
 		return
@@ -81,38 +106,82 @@ func (t *Trail) Back(trgLevel int) {
 	vals := t.Vars.Vals
 	reasons := t.Vars.Reasons
 	guess := t.Guess
+	cards := t.cards
 
 	i := t.Tail
 	dat := t.D
+	keep := t.keep[:0]
 
 	var m z.Lit
 	var v z.Var
 	var l int
+	var r z.C
 
 	for i > 0 {
 		i--
 		m = dat[i]
 		v = m.Var()
 		l = lvls[v]
-		if l == trgLevel {
-			t.Head = i + 1
-			t.Tail = t.Head
-			t.Level = trgLevel
-			return
+		if l <= trgLevel {
+			keep = append(keep, m)
+			continue
 		}
+		r = reasons[v]
 		vals[m] = 0
 		vals[m.Not()] = 0
 		reasons[v] = CNull
 		lvls[v] = -1
 		guess.Push(m) // actually only adds it if it is not already there.
+		if cards != nil {
+			cards.unassign(m)
+		}
+		if r == CNull && l == trgLevel+1 {
+			break
+		}
+	}
+	// i is the position of the first decision undone, or 0 when there
+	// are no units at level 0 and backtrack to level 0.
+	t.Tail = i
+	for j := len(keep) - 1; j >= 0; j-- {
+		dat[t.Tail] = keep[j]
+		t.Tail++
+	}
+	if t.Head > i {
+		t.Head = i
 	}
-	// this happens when there are no units at level 0 and backtrack to level 0.
-	t.Head = 0
-	t.Tail = 0
-	t.Level = 0
+	if t.xors != nil && t.xors.head > i {
+		t.xors.head = i
+	}
+	if cards != nil && cards.head > i {
+		cards.head = i
+	}
+	t.Level = trgLevel
+	t.keep = keep[:0]
 }
 
+// Prop propagates the assigned literals by unit propagation and, if there
+// are cardinality or xor constraints, by counting and by Gauss-Jordan
+// elimination until reaching a fixed point or a conflict, which Prop
+// returns.
 func (t *Trail) Prop() z.C {
+	x := t.prop()
+	for x == CNull {
+		switch {
+		case t.cards != nil && t.cards.ready(t):
+			x = t.cards.prop(t)
+		case t.xors != nil && t.xors.ready(t):
+			x = t.xors.prop(t)
+		default:
+			return CNull
+		}
+		if x == CNull {
+			x = t.prop()
+		}
+	}
+	return x
+}
+
+func (t *Trail) prop() z.C {
 	vals := t.Vars.Vals
 	data := t.D
 	watches := t.Vars.Watches
@@ -260,6 +329,10 @@ func (t *Trail) backWithLates(prevLevel int) {
 			}
 			continue
 		}
+		if levels[m.Var()] <= prevLevel {
+			// kept by Back.
+			continue
+		}
 		q := r + 1
 		hasCur := false
 		for !hasCur {
@@ -279,10 +352,75 @@ func (t *Trail) backWithLates(prevLevel int) {
 	}
 	t.Back(prevLevel)
 	for _, late := range lates {
-		// TBD: correct levels (also in main solve loop)
+		// TBD: correct levels (also in main solve loop) unless
+		// t.chrono.
 		t.Assign(late.m, late.r)
 	}
-	t.Tail = t.Head
+}
+
+// reasonLevel returns the level at which the reason c implies its first
+// literal, the maximum level of its other literals.
+func (t *Trail) reasonLevel(c z.C) int {
+	lvls := t.Vars.Levels
+	d := t.Cdb.CDat.D
+	l := 0
+	for p := c + 1; d[p] != z.LitNull; p++ {
+		if k := lvls[d[p].Var()]; k > l {
+			l = k
+		}
+	}
+	return l
+}
+
+// conflictLevel returns the level of the conflict x, the maximum level of
+// its literals, together with the number of its literals at that level
+// and one of them.  Under chronological backtracking, the level of a
+// conflict may be lower than t.Level.
+func (t *Trail) conflictLevel(x z.C) (int, int, z.Lit) {
+	lvls := t.Vars.Levels
+	d := t.Cdb.CDat.D
+	l, n, f := 0, 0, z.LitNull
+	for p := x; d[p] != z.LitNull; p++ {
+		m := d[p]
+		switch k := lvls[m.Var()]; {
+		case k > l:
+			l, n, f = k, 1, m
+		case k == l:
+			n++
+		}
+	}
+	return l, n, f
+}
+
+// backToConflict backtracks to the level of the conflict x.  If x has a
+// single literal at its level and that level is above minLevel, then x is
+// a missed implication of that literal: backToConflict backtracks one
+// level further, assigns the literal with reason x and returns CNull.
+// Otherwise, backToConflict returns x.
+func (t *Trail) backToConflict(x z.C, minLevel int) z.C {
+	l, n, f := t.conflictLevel(x)
+	t.Back(l)
+	if n != 1 || l <= minLevel {
+		return x
+	}
+	t.Back(l - 1)
+	cdb := t.Cdb
+	d := cdb.CDat.D
+	cdb.watchAt(x, 0, f)
+	if d[x+2] != z.LitNull {
+		// watch the highest other literal, which is the first to be
+		// unassigned by subsequent backtracking.
+		lvls := t.Vars.Levels
+		h := d[x+1]
+		for p := x + 2; d[p] != z.LitNull; p++ {
+			if lvls[d[p].Var()] > lvls[h.Var()] {
+				h = d[p]
+			}
+		}
+		cdb.watchAt(x, 1, h)
+	}
+	t.Assign(f, x)
+	return CNull
 }
 
 func (t *Trail) String() string {
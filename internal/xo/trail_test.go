@@ -97,3 +97,45 @@ func TestTrailTernary(t *testing.T) {
 		}
 	}
 }
+
+func TestTrailChrono(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	st := NewStats()
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 150, 560+rng.Intn(60))
+		s := NewS()
+		s.SetOptions(&Options{Chrono: true, ChronoLevels: 1})
+		addCnf(s, cnf)
+		for j := 0; j < 4; j++ {
+			var as []z.Lit
+			for k := 0; k < 3; k++ {
+				m := z.Var(rng.Intn(150) + 1).Pos()
+				if rng.Intn(2) == 0 {
+					m = m.Not()
+				}
+				as = append(as, m)
+			}
+			o := NewS()
+			addCnf(o, cnf)
+			s.Assume(as...)
+			o.Assume(as...)
+			r := s.Solve()
+			if r != o.Solve() {
+				t.Fatalf("chrono result %d", r)
+			}
+			if r != 1 {
+				continue
+			}
+			checkCnf(t, s, cnf)
+			for _, m := range as {
+				if !s.Value(m) {
+					t.Fatalf("assumption %s false", m)
+				}
+			}
+		}
+		s.ReadStats(st)
+	}
+	if st.ChronoBacks == 0 {
+		t.Errorf("no chronological backtracks")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import "github.com/go-air/gini/z"
+
+// minimum propagation budget of a vivification pass.
+const vivifyMinProps = 20000
+
+// vivifyReady returns whether a vivification pass may run, which requires
+// decision level 0 and a sufficient propagation budget.
+func (s *S) vivifyReady() bool {
+	if s.opts.VivifyEffort <= 0 || s.Trail.Level != 0 || s.Cdb.traceHints {
+		return false
+	}
+	return s.vivifyBudget() >= vivifyMinProps
+}
+
+// vivifyBudget returns the number of propagations available for
+// vivification, a fraction s.opts.VivifyEffort of those in search.
+func (s *S) vivifyBudget() int64 {
+	search := s.Trail.Props - s.vivifyProps
+	return int64(s.opts.VivifyEffort*float64(search)) - s.vivifyProps
+}
+
+// vivify tries to shorten learnt clauses, and irredundant clauses if
+// s.opts.VivifyIrredundant, by propagating the negations of their
+// literals at decision level 0 until a conflict occurs or a literal of
+// the clause is implied.  Satisfied clauses are removed.
+//
+// vivify returns CNull, or a conflict at level 0 if a unit resulting from
+// vivification leads to one.
+func (s *S) vivify() z.C {
+	trail := s.Trail
+	start := trail.Props
+	budget := s.vivifyBudget()
+	defer func() {
+		s.vivifyProps += trail.Props - start
+	}()
+	// decisions during vivification must not change the saved phases.
+	cache := append([]int8(nil), s.Guess.cache...)
+	defer copy(s.Guess.cache, cache)
+
+	cdb := s.Cdb
+	var rms []z.C
+	x := CNull
+	vivifyAll := func(ps []z.C, next *int) {
+		if *next >= len(ps) {
+			*next = 0
+		}
+		for ; *next < len(ps); *next++ {
+			if x != CNull || trail.Props-start >= budget {
+				return
+			}
+			p := ps[*next]
+			h := cdb.Chd(p)
+			if h.Learnt() && cdb.gc.tier(h) == tierLocal {
+				continue
+			}
+			if cdb.IsBinary(p) || cdb.IsUnit(p) || cdb.InUse(p) {
+				continue
+			}
+			var rm bool
+			rm, x = s.vivifyClause(p)
+			if rm {
+				rms = append(rms, p)
+			}
+		}
+	}
+	vivifyAll(cdb.Learnts, &s.vivifyLearnt)
+	if s.opts.VivifyIrredundant && cdb.Active == nil {
+		vivifyAll(cdb.Added, &s.vivifyAdded)
+	}
+	if len(rms) == 0 {
+		return x
+	}
+	cdb.Learnts = removeLocs(cdb.Learnts, rms)
+	cdb.Added = removeLocs(cdb.Added, rms)
+	cdb.gc.Remove(cdb, rms...)
+	return x
+}
+
+// vivifyClause vivifies the clause p, returning whether p should be
+// removed and a conflict at level 0, if any.
+func (s *S) vivifyClause(p z.C) (bool, z.C) {
+	trail := s.Trail
+	cdb := s.Cdb
+	vals := s.Vars.Vals
+	s.stVivifyChecked++
+	ms := cdb.CDat.Load(p, s.vivifyLits[:0])
+	s.vivifyLits = ms
+	for _, m := range ms {
+		if vals[m] == 1 {
+			return true, CNull
+		}
+	}
+	cdb.Unlink([]z.C{p})
+	ns := make([]z.Lit, 0, len(ms))
+	for _, m := range ms {
+		switch vals[m] {
+		case 1:
+			// implied by the negations of ns, without p.
+			ns = append(ns, m)
+			goto Done
+		case -1:
+			// implied false, without p.
+			continue
+		}
+		ns = append(ns, m)
+		trail.Assign(m.Not(), CNull)
+		if x := trail.Prop(); x != CNull {
+			goto Done
+		}
+	}
+Done:
+	trail.Back(0)
+	if len(ns) == len(ms) || len(ns) == 0 {
+		cdb.link(p, ms)
+		return false, CNull
+	}
+	s.stVivified++
+	s.stVivifiedLits += int64(len(ms) - len(ns))
+	q := cdb.strengthen(p, ns)
+	if len(ns) == 1 {
+		trail.Assign(ns[0], q)
+		if x := trail.Prop(); x != CNull {
+			return true, x
+		}
+	}
+	return true, CNull
+}
+
+// removeLocs returns ps without the elements of rms.
+func removeLocs(ps, rms []z.C) []z.C {
+	rMap := make(map[z.C]bool, len(rms))
+	for _, p := range rms {
+		rMap[p] = true
+	}
+	j := 0
+	for _, p := range ps {
+		if rMap[p] {
+			continue
+		}
+		ps[j] = p
+		j++
+	}
+	return ps[:j]
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"testing"
+
+	"github.com/go-air/gini/gen"
+)
+
+func TestVivify(t *testing.T) {
+	st := NewStats()
+	for i := 0; i < 20; i++ {
+		s := NewS()
+		gen.Rand3Cnf(s, 150, 640)
+		o := s.Copy()
+		s.SetOptions(&Options{VivifyEffort: 1, VivifyIrredundant: true})
+		o.SetOptions(&Options{VivifyEffort: -1})
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Errorf("vivified result %d", r)
+		}
+		s.ReadStats(st)
+	}
+	if st.Vivified == 0 {
+		t.Errorf("nothing vivified")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"sort"
+
+	"github.com/go-air/gini/z"
+)
+
+// Type vmtf is a variable move-to-front decision queue.  Bumped variables
+// are moved to the end of the queue, and guesses are taken starting from
+// the end.
+//
+// The search position is such that all variables enqueued after it are
+// assigned.
+type vmtf struct {
+	prev   []z.Var
+	next   []z.Var
+	stamps []int64
+	first  z.Var
+	last   z.Var
+	search z.Var
+	stamp  int64
+	bumped []z.Var
+}
+
+func newVmtf(capHint int) *vmtf {
+	return &vmtf{
+		prev:   make([]z.Var, capHint),
+		next:   make([]z.Var, capHint),
+		stamps: make([]int64, capHint)}
+}
+
+// enqueue puts v at the end of the queue.
+func (q *vmtf) enqueue(v z.Var) {
+	q.stamp++
+	q.stamps[v] = q.stamp
+	q.prev[v] = q.last
+	q.next[v] = 0
+	if q.last == 0 {
+		q.first = v
+	} else {
+		q.next[q.last] = v
+	}
+	q.last = v
+}
+
+// dequeue removes v from the queue.
+func (q *vmtf) dequeue(v z.Var) {
+	p, n := q.prev[v], q.next[v]
+	if p == 0 {
+		q.first = n
+	} else {
+		q.next[p] = n
+	}
+	if n == 0 {
+		q.last = p
+	} else {
+		q.prev[n] = p
+	}
+	if q.search == v {
+		q.search = p
+		if p == 0 {
+			q.search = n
+		}
+	}
+}
+
+// bump records that v should be moved to the end of the queue by the
+// next call to flush.
+func (q *vmtf) bump(v z.Var) {
+	q.bumped = append(q.bumped, v)
+}
+
+// flush moves the bumped variables to the end of the queue, preserving
+// their relative order.
+func (q *vmtf) flush() {
+	bumped := q.bumped
+	if len(bumped) == 0 {
+		return
+	}
+	sort.Slice(bumped, func(i, j int) bool {
+		return q.stamps[bumped[i]] < q.stamps[bumped[j]]
+	})
+	for _, v := range bumped {
+		if q.last != v {
+			q.dequeue(v)
+			q.enqueue(v)
+		}
+	}
+	q.bumped = bumped[:0]
+	q.search = q.last
+}
+
+// unassign updates the search position when v becomes unassigned,
+// enqueueing v if it is not yet in the queue.
+func (q *vmtf) unassign(v z.Var) {
+	if q.stamps[v] == 0 {
+		q.enqueue(v)
+	}
+	if q.search == 0 || q.stamps[v] > q.stamps[q.search] {
+		q.search = v
+	}
+}
+
+// guess returns the last unassigned variable in the queue, or 0 if
+// there is none.
+func (q *vmtf) guess(vals []int8) z.Var {
+	v := q.search
+	for v != 0 && vals[v.Pos()] != 0 {
+		v = q.prev[v]
+	}
+	q.search = v
+	return v
+}
+
+// reset places the search position at the end of the queue.
+func (q *vmtf) reset() {
+	q.search = q.last
+}
+
+func (q *vmtf) growToVar(u z.Var) {
+	w := int(u + 1)
+	p := make([]z.Var, w)
+	copy(p, q.prev)
+	n := make([]z.Var, w)
+	copy(n, q.next)
+	s := make([]int64, w)
+	copy(s, q.stamps)
+	q.prev, q.next, q.stamps = p, n, s
+}
+
+func (q *vmtf) Copy() *vmtf {
+	other := &vmtf{
+		prev:   make([]z.Var, len(q.prev), cap(q.prev)),
+		next:   make([]z.Var, len(q.next), cap(q.next)),
+		stamps: make([]int64, len(q.stamps), cap(q.stamps)),
+		first:  q.first,
+		last:   q.last,
+		search: q.search,
+		stamp:  q.stamp}
+	copy(other.prev, q.prev)
+	copy(other.next, q.next)
+	copy(other.stamps, q.stamps)
+	return other
+}
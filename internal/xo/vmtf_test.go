@@ -0,0 +1,57 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"testing"
+
+	"github.com/go-air/gini/z"
+)
+
+func TestVmtf(t *testing.T) {
+	N := 64
+	q := newVmtf(N + 1)
+	for i := 1; i <= N; i++ {
+		q.enqueue(z.Var(i))
+	}
+	q.reset()
+	vals := make([]int8, 2*(N+1))
+	assign := func(v z.Var) {
+		vals[v.Pos()] = 1
+		vals[v.Neg()] = -1
+	}
+	unassign := func(v z.Var) {
+		vals[v.Pos()] = 0
+		vals[v.Neg()] = 0
+		q.unassign(v)
+	}
+	if v := q.guess(vals); v != z.Var(N) {
+		t.Errorf("guessed %s not %s", v, z.Var(N))
+	}
+	for _, v := range []z.Var{5, 3, 9} {
+		assign(v)
+		q.bump(v)
+	}
+	q.flush()
+	if v := q.guess(vals); v != z.Var(N) {
+		t.Errorf("guessed assigned %s", v)
+	}
+	for i := 1; i <= N; i++ {
+		assign(z.Var(i))
+	}
+	if v := q.guess(vals); v != 0 {
+		t.Errorf("guessed %s with all assigned", v)
+	}
+	// bumped variables keep their relative order.
+	for _, v := range []z.Var{9, 3, 5, 1} {
+		unassign(v)
+	}
+	for _, exp := range []z.Var{9, 5, 3, 1} {
+		v := q.guess(vals)
+		if v != exp {
+			t.Errorf("guessed %s not %s", v, exp)
+		}
+		assign(v)
+	}
+}
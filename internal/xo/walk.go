@@ -0,0 +1,295 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-air/gini/z"
+)
+
+const (
+	walkFlips = 100000
+	walkCb    = 2.5 // probsat polynomial break base
+	walkEps   = 1.0
+	walkBrks  = 16      // precomputed break weights
+	walkTick  = 1 << 12 // flips between checks of the deadline in Try
+)
+
+// Walk is a ProbSAT style stochastic local search over the added clauses
+// of an S.  Walk implements inter.Solvable and inter.Model: Solve and Try
+// return 1 if Walk finds a model and 0 otherwise, never -1.
+//
+// A Walk is a snapshot of the clauses of its S when it is created, and
+// does not change the S.  Variables eliminated from the S are given
+// values as the S gives them, and cardinality, pseudo-Boolean and xor
+// constraints are checked on the models found, but do not guide the
+// search.
+type Walk struct {
+	s     *S
+	rng   *rand.Rand
+	fixed []int8 // values of the fixed variables
+	empty bool   // whether a clause is false under the fixed variables
+	cls   [][]z.Lit
+	occs  [][]int // by literal
+	as    []bool  // true iff the variable is true
+	nTrue []int   // by clause
+	unsat []int
+	pos   []int // by clause, position in unsat or -1
+	best  []bool
+	nBest int
+	ws    []float64
+	brks  [walkBrks]float64
+	vals  []int8 // the model, if found
+	model *elim
+
+	// Flips is the maximum number of flips made by each call to Solve.
+	Flips uint
+
+	stFlips int64
+}
+
+// NewWalk creates a local search over the added clauses of s, in which the
+// variables assigned at level 0 are fixed, starting from the cached
+// phases of s and randomized by seed.
+func NewWalk(s *S, seed int64) *Walk {
+	return newWalk(s, rand.New(rand.NewSource(seed)), 0)
+}
+
+// newWalk creates a Walk in which the variables assigned at level lvl or
+// below are fixed.
+func newWalk(s *S, rng *rand.Rand, lvl int) *Walk {
+	vals := s.Vars.Vals
+	lvls := s.Vars.Levels
+	M := s.Vars.Max
+	w := &Walk{
+		s:     s,
+		rng:   rng,
+		fixed: make([]int8, 2*M+2),
+		occs:  make([][]int, 2*M+2),
+		as:    make([]bool, M+1),
+		best:  make([]bool, M+1),
+		Flips: s.opts.WalkFlips}
+	for v := z.Var(1); v <= M; v++ {
+		m := v.Pos()
+		if vals[m] != 0 && lvls[v] <= lvl {
+			w.fixed[m] = vals[m]
+			w.fixed[m.Not()] = -vals[m]
+		}
+	}
+	s.Cdb.ForallAdded(func(p z.C, h Chd, ms []z.Lit) {
+		cs := make([]z.Lit, 0, len(ms))
+		for _, m := range ms {
+			switch w.fixed[m] {
+			case 1:
+				return
+			case 0:
+				cs = append(cs, m)
+			}
+		}
+		if len(cs) == 0 {
+			w.empty = true
+			return
+		}
+		k := len(w.cls)
+		w.cls = append(w.cls, cs)
+		for _, m := range cs {
+			w.occs[m] = append(w.occs[m], k)
+		}
+	})
+	for i := range w.brks {
+		w.brks[i] = math.Pow(walkEps+float64(i), -walkCb)
+	}
+	cache := s.Guess.cache
+	for v := z.Var(1); v <= M; v++ {
+		w.as[v] = cache[v] == 1
+	}
+	w.nTrue = make([]int, len(w.cls))
+	w.pos = make([]int, len(w.cls))
+	for k, cs := range w.cls {
+		for _, m := range cs {
+			if w.isTrue(m) {
+				w.nTrue[k]++
+			}
+		}
+		w.pos[k] = -1
+		if w.nTrue[k] == 0 {
+			w.pos[k] = len(w.unsat)
+			w.unsat = append(w.unsat, k)
+		}
+	}
+	copy(w.best, w.as)
+	w.nBest = len(w.unsat)
+	if w.empty {
+		w.nBest++
+	}
+	return w
+}
+
+// Solve makes at most w.Flips flips, returning 1 if a model is found and 0
+// otherwise.
+func (w *Walk) Solve() int {
+	w.flips(uint64(w.Flips))
+	return w.result()
+}
+
+// Try flips until a model is found, returning 1, or until dur has elapsed,
+// returning 0.
+func (w *Walk) Try(dur time.Duration) int {
+	deadline := time.Now().Add(dur)
+	for w.nBest > 0 && time.Now().Before(deadline) {
+		w.flips(walkTick)
+	}
+	return w.result()
+}
+
+// Value returns the value of m in the model found by the last call to
+// Solve or Try returning 1.  Otherwise, the result is undefined.
+func (w *Walk) Value(m z.Lit) bool {
+	if w.model == nil {
+		return false
+	}
+	return w.model.value(m, w.vals) == 1
+}
+
+// Unsat returns the least number of false clauses under an assignment
+// found so far.
+func (w *Walk) Unsat() int {
+	return w.nBest
+}
+
+// Flipped returns the number of flips made so far.
+func (w *Walk) Flipped() int64 {
+	return w.stFlips
+}
+
+// result returns 1 if the best assignment satisfies all constraints,
+// setting the model of w, and 0 otherwise.
+func (w *Walk) result() int {
+	s := w.s
+	if w.nBest > 0 || s.Cdb.Bot != CNull {
+		return 0
+	}
+	vals := make([]int8, len(w.fixed))
+	copy(vals, w.fixed)
+	for v := z.Var(1); v < z.Var(len(w.best)); v++ {
+		m := v.Pos()
+		if vals[m] != 0 {
+			continue
+		}
+		vals[m], vals[m.Not()] = -1, 1
+		if w.best[v] {
+			vals[m], vals[m.Not()] = 1, -1
+		}
+	}
+	if s.cards != nil && !s.cards.sat(vals) {
+		return 0
+	}
+	if s.xors != nil && !s.xors.sat(vals) {
+		return 0
+	}
+	w.vals = vals
+	// extend the model to the eliminated variables without changing
+	// the model of s.
+	e := *s.elim
+	e.vals = make([]int8, len(e.vals))
+	e.extend(vals)
+	w.model = &e
+	return 1
+}
+
+func (w *Walk) isTrue(m z.Lit) bool {
+	return w.as[m.Var()] == m.IsPos()
+}
+
+// flips makes at most n flips, stopping when all clauses are true.
+func (w *Walk) flips(n uint64) {
+	cls, occs, nTrue, pos := w.cls, w.occs, w.nTrue, w.pos
+	rng := w.rng
+	if w.empty {
+		return
+	}
+	for i := uint64(0); i < n && len(w.unsat) > 0; i++ {
+		cs := cls[w.unsat[rng.Intn(len(w.unsat))]]
+		ws := w.ws[:0]
+		sum := 0.0
+		for _, m := range cs {
+			brk := 0
+			for _, k := range occs[m.Not()] {
+				if nTrue[k] == 1 {
+					brk++
+				}
+			}
+			x := 0.0
+			if brk < walkBrks {
+				x = w.brks[brk]
+			} else {
+				x = math.Pow(walkEps+float64(brk), -walkCb)
+			}
+			sum += x
+			ws = append(ws, x)
+		}
+		w.ws = ws
+		r := rng.Float64() * sum
+		j := 0
+		for ; j < len(ws)-1; j++ {
+			r -= ws[j]
+			if r <= 0 {
+				break
+			}
+		}
+		t := cs[j]
+		w.as[t.Var()] = t.IsPos()
+		for _, k := range occs[t] {
+			nTrue[k]++
+			if nTrue[k] == 1 {
+				j := pos[k]
+				last := w.unsat[len(w.unsat)-1]
+				w.unsat[j] = last
+				pos[last] = j
+				w.unsat = w.unsat[:len(w.unsat)-1]
+				pos[k] = -1
+			}
+		}
+		for _, k := range occs[t.Not()] {
+			nTrue[k]--
+			if nTrue[k] == 0 {
+				pos[k] = len(w.unsat)
+				w.unsat = append(w.unsat, k)
+			}
+		}
+		w.stFlips++
+		if len(w.unsat) < w.nBest {
+			w.nBest = len(w.unsat)
+			copy(w.best, w.as)
+		}
+	}
+}
+
+// walk runs a local search over the added clauses starting from the
+// cached phases, making at most s.opts.WalkFlips flips.  The cached
+// phases are then set to the assignment with the fewest false clauses
+// found.  Variables assigned in s are fixed.
+//
+// walk returns the number of clauses false under the resulting phases.
+func (s *S) walk(rng *rand.Rand) int {
+	w := newWalk(s, rng, s.Trail.Level)
+	w.flips(uint64(s.opts.WalkFlips))
+	s.stWalkFlips += w.stFlips
+	vals := s.Vars.Vals
+	cache := s.Guess.cache
+	for v := z.Var(1); v <= s.Vars.Max; v++ {
+		if vals[v.Pos()] != 0 {
+			continue
+		}
+		if w.best[v] {
+			cache[v] = 1
+		} else {
+			cache[v] = -1
+		}
+	}
+	return w.nBest
+}
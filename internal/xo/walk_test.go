@@ -0,0 +1,89 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/z"
+)
+
+func TestWalk(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := NewS()
+		gen.Rand3Cnf(s, 100, 300)
+		if s.solveInit() == -1 {
+			continue
+		}
+		if n := s.walk(rand.New(rand.NewSource(int64(i)))); n != 0 {
+			t.Errorf("walk left %d false clauses", n)
+		}
+		// the walk phases give a model without conflicts.
+		if s.Solve() != 1 {
+			t.Errorf("not sat")
+		}
+		st := NewStats()
+		s.ReadStats(st)
+		if st.Conflicts != 0 {
+			t.Errorf("%d conflicts after walk", st.Conflicts)
+		}
+	}
+}
+
+func TestWalkSolve(t *testing.T) {
+	// instances which are known to be sat.
+	gen.Seed(29)
+	sats := 0
+	for i := 0; i < 10; i++ {
+		a := &clsRec{}
+		gen.Rand3Cnf(a, 200, 800)
+		s := NewS()
+		if i%2 == 1 {
+			s.SetOptions(&Options{Eliminate: true, Substitute: true})
+		}
+		addCnf(s, a.cs)
+		if s.solveInit() == -1 {
+			continue
+		}
+		w := NewWalk(s, int64(i))
+		w.Flips = 1000000
+		if w.Solve() != 1 {
+			t.Errorf("walk left %d false clauses", w.Unsat())
+			continue
+		}
+		sats++
+		for _, c := range a.cs {
+			sat := false
+			for _, m := range c {
+				if w.Value(m) {
+					sat = true
+				}
+			}
+			if !sat {
+				t.Fatalf("walk model does not satisfy %v", c)
+			}
+		}
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+
+	s := NewS()
+	gen.Php(s, 5, 4)
+	if r := NewWalk(s, 1).Try(10 * time.Millisecond); r != 0 {
+		t.Errorf("walk php result %d", r)
+	}
+
+	// xors are checked but not searched.
+	s = NewS()
+	addCnf(s, [][]z.Lit{{z.Var(1).Pos()}, {z.Var(1).Pos(), z.Var(2).Pos()}})
+	s.AddXor([]z.Lit{z.Var(2).Pos(), z.Var(3).Pos()}, true)
+	w := NewWalk(s, 1)
+	if w.Solve() == 1 && w.Value(z.Var(2).Pos()) == w.Value(z.Var(3).Pos()) {
+		t.Errorf("walk model violates xor")
+	}
+}
@@ -0,0 +1,512 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/bits"
+
+	"github.com/go-air/gini/z"
+)
+
+// xorRow is a row of the xor matrix, stating that the xor of the
+// variables of its columns is rhs.
+type xorRow struct {
+	bits    []uint64
+	rhs     bool
+	basic   int // column of the row occurring in no other row
+	watch   int // column of the row other than basic, or -1
+	pending bool
+}
+
+// xors holds xor constraints as the rows of a matrix over GF(2) kept in
+// reduced row echelon form, and propagates them by incremental
+// Gauss-Jordan elimination next to unit propagation.
+//
+// Each row has a basic column, which occurs in no other row, and a
+// watched column.  Both are unassigned unless the row has at most one
+// unassigned column.  When the basic column of a row is assigned, the row
+// pivots on another unassigned column, eliminating it from the other
+// rows.  When a row has one unassigned column, it implies its value, and
+// when it has none and its parity differs from rhs, it is a conflict.
+// Since row operations preserve the solutions of the matrix regardless
+// of the assignment, nothing is undone when backtracking.
+//
+// Implications and conflicts are given reasons by adding learnt clauses,
+// the literals of the row false under the assignment together with the
+// implied literal, so that conflict analysis works as for clauses.
+type xors struct {
+	vars     []z.Var // by column
+	cols     []int   // by variable, column+1 or 0
+	rows     []xorRow
+	words    int
+	basics   []int   // by column, row or -1
+	watchers [][]int // by column, rows which may watch it
+	pending  []int
+	head     int // trail position of the next assignment to process
+	ms       []z.Lit
+
+	stRows      int64
+	stProps     int64
+	stConflicts int64
+	stPivots    int64
+}
+
+func newXors(capHint int) *xors {
+	return &xors{
+		cols: make([]int, capHint+1)}
+}
+
+// Copy returns a copy of x.
+func (x *xors) Copy() *xors {
+	if x == nil {
+		return nil
+	}
+	other := &xors{
+		vars:        append([]z.Var(nil), x.vars...),
+		cols:        append([]int(nil), x.cols...),
+		rows:        make([]xorRow, len(x.rows)),
+		words:       x.words,
+		basics:      append([]int(nil), x.basics...),
+		watchers:    make([][]int, len(x.watchers)),
+		pending:     append([]int(nil), x.pending...),
+		head:        x.head,
+		stRows:      x.stRows,
+		stProps:     x.stProps,
+		stConflicts: x.stConflicts,
+		stPivots:    x.stPivots}
+	for i := range x.rows {
+		other.rows[i] = x.rows[i]
+		other.rows[i].bits = append([]uint64(nil), x.rows[i].bits...)
+	}
+	for i := range x.watchers {
+		other.watchers[i] = append([]int(nil), x.watchers[i]...)
+	}
+	return other
+}
+
+func (x *xors) growToVar(u z.Var) {
+	cols := make([]int, u+1)
+	copy(cols, x.cols)
+	x.cols = cols
+}
+
+func (x *xors) readStats(st *Stats) {
+	st.Xors += x.stRows
+	x.stRows = 0
+	st.XorProps += x.stProps
+	x.stProps = 0
+	st.XorConflicts += x.stConflicts
+	x.stConflicts = 0
+	st.XorPivots += x.stPivots
+	x.stPivots = 0
+}
+
+// col returns the column of v, creating it if need be.
+func (x *xors) col(v z.Var) int {
+	if c := x.cols[v]; c != 0 {
+		return c - 1
+	}
+	c := len(x.vars)
+	x.vars = append(x.vars, v)
+	x.cols[v] = c + 1
+	x.basics = append(x.basics, -1)
+	x.watchers = append(x.watchers, nil)
+	if c/64 >= x.words {
+		x.words++
+		for i := range x.rows {
+			x.rows[i].bits = append(x.rows[i].bits, 0)
+		}
+	}
+	return c
+}
+
+// add adds the row stating that the xor of vs is rhs, in which each
+// variable occurs once.  add returns false if the row contradicts the
+// matrix.
+func (x *xors) add(vs []z.Var, rhs bool) bool {
+	r := xorRow{bits: make([]uint64, x.words), watch: -1}
+	for _, v := range vs {
+		c := x.col(v)
+		if len(r.bits) < x.words {
+			r.bits = append(r.bits, make([]uint64, x.words-len(r.bits))...)
+		}
+		r.bits[c/64] |= 1 << uint(c%64)
+	}
+	r.rhs = rhs
+	// eliminate the basic columns of the other rows.
+	for c := range x.vars {
+		if i := x.basics[c]; i >= 0 && r.has(c) {
+			r.xor(&x.rows[i])
+		}
+	}
+	basic := r.first()
+	if basic == -1 {
+		return !r.rhs
+	}
+	i := len(x.rows)
+	r.basic = basic
+	x.rows = append(x.rows, r)
+	x.basics[basic] = i
+	x.eliminate(i)
+	x.queue(i)
+	x.stRows++
+	return true
+}
+
+// sat returns whether the total assignment vals satisfies the rows of x,
+// and so the xor constraints added to x.
+func (x *xors) sat(vals []int8) bool {
+	for i := range x.rows {
+		p := false
+		for k, word := range x.rows[i].bits {
+			for word != 0 {
+				c := k*64 + bits.TrailingZeros64(word)
+				word &= word - 1
+				if x.val(vals, c) == 1 {
+					p = !p
+				}
+			}
+		}
+		if p != x.rows[i].rhs {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminate removes the basic column of row i from the other rows.
+func (x *xors) eliminate(i int) {
+	r := &x.rows[i]
+	for j := range x.rows {
+		if j != i && x.rows[j].has(r.basic) {
+			x.rows[j].xor(r)
+			x.queue(j)
+		}
+	}
+}
+
+// pivot makes the column c the basic column of row i.
+func (x *xors) pivot(i, c int) {
+	r := &x.rows[i]
+	x.basics[r.basic] = -1
+	r.basic = c
+	x.basics[c] = i
+	x.eliminate(i)
+	x.stPivots++
+}
+
+// queue schedules row i to be checked.
+func (x *xors) queue(i int) {
+	if x.rows[i].pending {
+		return
+	}
+	x.rows[i].pending = true
+	x.pending = append(x.pending, i)
+}
+
+// ready returns whether x has rows to check or assignments to process.
+func (x *xors) ready(t *Trail) bool {
+	return len(x.pending) > 0 || x.head < t.Tail
+}
+
+// prop processes the assignments of the trail t since the last call,
+// checking the rows whose basic or watched columns are assigned.  prop
+// returns CNull or a conflict.
+func (x *xors) prop(t *Trail) z.C {
+	for {
+		for len(x.pending) > 0 {
+			i := x.pending[len(x.pending)-1]
+			x.pending = x.pending[:len(x.pending)-1]
+			x.rows[i].pending = false
+			if p := x.check(t, i); p != CNull {
+				// check again after backtracking.
+				x.queue(i)
+				return p
+			}
+		}
+		if x.head >= t.Tail {
+			return CNull
+		}
+		v := t.D[x.head].Var()
+		x.head++
+		c := x.cols[v] - 1
+		if c < 0 {
+			continue
+		}
+		if i := x.basics[c]; i >= 0 {
+			x.queue(i)
+		}
+		ws := x.watchers[c]
+		j := 0
+		for _, i := range ws {
+			if x.rows[i].watch != c {
+				continue
+			}
+			ws[j] = i
+			j++
+			x.queue(i)
+		}
+		x.watchers[c] = ws[:j]
+	}
+}
+
+// check checks row i under the current assignment.  If the row has at
+// least two unassigned columns, check makes its basic and watched
+// columns unassigned.  Otherwise, check propagates the unassigned column
+// or returns a conflict if there is none and the parity of the row is
+// wrong.  In either case, the watched column is left at the highest
+// level, so that backtracking unassigns it first.
+func (x *xors) check(t *Trail, i int) z.C {
+	vals := t.Vars.Vals
+	r := &x.rows[i]
+	a, b := -1, -1
+	if x.val(vals, r.basic) == 0 {
+		a = r.basic
+	}
+	if w := r.watch; w >= 0 && w != r.basic && r.has(w) && x.val(vals, w) == 0 {
+		if a == -1 {
+			a = w
+		} else {
+			b = w
+		}
+	}
+	parity := r.rhs
+	for k, word := range r.bits {
+		if b != -1 {
+			break
+		}
+		for word != 0 {
+			c := k*64 + bits.TrailingZeros64(word)
+			word &= word - 1
+			switch x.val(vals, c) {
+			case 0:
+				if c == a {
+					continue
+				}
+				if a == -1 {
+					a = c
+				} else {
+					b = c
+				}
+			case 1:
+				parity = !parity
+			}
+			if b != -1 {
+				break
+			}
+		}
+	}
+	if b != -1 {
+		if r.basic != a && r.basic != b {
+			x.pivot(i, a)
+			r = &x.rows[i]
+		}
+		w := a
+		if w == r.basic {
+			w = b
+		}
+		x.watchAt(i, w)
+		return CNull
+	}
+	if a != -1 {
+		m := x.vars[a].Pos()
+		if !parity {
+			m = m.Not()
+		}
+		x.stProps++
+		p := x.reason(t, i, m)
+		if a != r.basic {
+			x.watchAt(i, a)
+		}
+		t.Assign(m, p)
+		return CNull
+	}
+	if parity {
+		x.stConflicts++
+		return x.reason(t, i, z.LitNull)
+	}
+	if w := x.highest(t, i); w != r.basic {
+		x.watchAt(i, w)
+	}
+	return CNull
+}
+
+// watchAt makes column c the watched column of row i.
+func (x *xors) watchAt(i, c int) {
+	if x.rows[i].watch == c {
+		return
+	}
+	x.rows[i].watch = c
+	x.watchers[c] = append(x.watchers[c], i)
+}
+
+// highest returns the column of row i assigned at the highest level.
+func (x *xors) highest(t *Trail, i int) int {
+	lvls := t.Vars.Levels
+	h, hl := -1, -1
+	for k, word := range x.rows[i].bits {
+		for word != 0 {
+			c := k*64 + bits.TrailingZeros64(word)
+			word &= word - 1
+			if l := lvls[x.vars[c]]; l > hl {
+				h, hl = c, l
+			}
+		}
+	}
+	return h
+}
+
+// reason adds and returns the learnt clause consisting of m, unless m is
+// z.LitNull, and the literals of row i false under the current
+// assignment, with the highest level literals watched.
+func (x *xors) reason(t *Trail, i int, m z.Lit) z.C {
+	vals := t.Vars.Vals
+	lvls := t.Vars.Levels
+	ms := x.ms[:0]
+	start := 0
+	if m != z.LitNull {
+		ms = append(ms, m)
+		start = 1
+	}
+	for k, word := range x.rows[i].bits {
+		for word != 0 {
+			c := k*64 + bits.TrailingZeros64(word)
+			word &= word - 1
+			v := x.vars[c]
+			if m != z.LitNull && v == m.Var() {
+				continue
+			}
+			n := v.Pos()
+			if vals[n] == 1 {
+				n = n.Not()
+			}
+			ms = append(ms, n)
+		}
+	}
+	for j := start; j < 2 && j < len(ms); j++ {
+		h := j
+		for k := j + 1; k < len(ms); k++ {
+			if lvls[ms[k].Var()] > lvls[ms[h].Var()] {
+				h = k
+			}
+		}
+		ms[j], ms[h] = ms[h], ms[j]
+	}
+	p := t.Cdb.addLearnt(ms, len(ms)-1, nil)
+	x.ms = ms[:0]
+	return p
+}
+
+func (x *xors) val(vals []int8, c int) int8 {
+	return vals[x.vars[c].Pos()]
+}
+
+// has returns whether column c is in r.
+func (r *xorRow) has(c int) bool {
+	return r.bits[c/64]&(1<<uint(c%64)) != 0
+}
+
+// xor adds o to r.
+func (r *xorRow) xor(o *xorRow) {
+	for k, word := range o.bits {
+		r.bits[k] ^= word
+	}
+	r.rhs = r.rhs != o.rhs
+}
+
+// first returns the first column of r, or -1 if r is empty.
+func (r *xorRow) first() int {
+	for k, word := range r.bits {
+		if word != 0 {
+			return k*64 + bits.TrailingZeros64(word)
+		}
+	}
+	return -1
+}
+
+// clauses per xor constraint added as clauses are at most 1<<(xorChunk-1).
+const xorChunk = 5
+
+// AddXor adds the constraint that the number of true literals in ms is
+// odd if rhs is true and even otherwise.  The variables of ms are frozen,
+// see Freeze.
+//
+// Xor constraints are propagated by Gauss-Jordan elimination, except when
+// a proof is written: then they are added as clauses, so that the proof
+// covers them.  Otherwise, they are not written by Write.
+func (s *S) AddXor(ms []z.Lit, rhs bool) {
+	for _, m := range ms {
+		s.ensureLitCap(m)
+	}
+	s.ensure0()
+	odd := make(map[z.Var]bool, len(ms))
+	vs := make([]z.Var, 0, len(ms))
+	for _, m := range ms {
+		if !m.IsPos() {
+			rhs = !rhs
+		}
+		v := m.Var()
+		if _, ok := odd[v]; !ok {
+			vs = append(vs, v)
+		}
+		odd[v] = !odd[v]
+	}
+	j := 0
+	for _, v := range vs {
+		if odd[v] {
+			vs[j] = v
+			j++
+		}
+	}
+	vs = vs[:j]
+	for _, v := range vs {
+		s.Freeze(v)
+	}
+	if s.Cdb.Tracer != nil {
+		s.addXorClauses(vs, rhs)
+		return
+	}
+	if s.xors == nil {
+		s.xors = newXors(int(s.Vars.Top))
+		s.xors.head = s.Trail.Tail
+		s.Trail.xors = s.xors
+	}
+	if !s.xors.add(vs, rhs) {
+		s.Cdb.addLearnt(nil, 0, nil)
+	}
+}
+
+// addXorClauses adds the xor of vs being rhs as clauses, chaining fresh
+// variables through chunks of at most xorChunk variables.
+func (s *S) addXorClauses(vs []z.Var, rhs bool) {
+	for len(vs) > xorChunk {
+		// t is the xor of the first xorChunk-1 variables.
+		t := s.Lit().Var()
+		chunk := make([]z.Var, 0, xorChunk)
+		chunk = append(chunk, vs[:xorChunk-1]...)
+		s.addXorCnf(append(chunk, t), false)
+		vs = append([]z.Var{t}, vs[xorChunk-1:]...)
+	}
+	s.addXorCnf(vs, rhs)
+}
+
+// addXorCnf adds the clauses excluding the assignments of vs whose parity
+// differs from rhs.
+func (s *S) addXorCnf(vs []z.Var, rhs bool) {
+	for neg := 0; neg < 1<<uint(len(vs)); neg++ {
+		// the clause excludes the assignment in which the variables
+		// of its negative literals are true.
+		if (bits.OnesCount(uint(neg))%2 == 1) == rhs {
+			continue
+		}
+		for i, v := range vs {
+			if neg&(1<<uint(i)) != 0 {
+				s.Add(v.Neg())
+			} else {
+				s.Add(v.Pos())
+			}
+		}
+		s.Add(z.LitNull)
+	}
+}
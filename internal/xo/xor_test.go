@@ -0,0 +1,197 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package xo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-air/gini/z"
+)
+
+type xorCons struct {
+	vs  []z.Var
+	rhs bool
+}
+
+// randXors returns m random xor constraints over n variables with
+// between 2 and 8 distinct variables each.
+func randXors(rng *rand.Rand, n, m int) []xorCons {
+	var xs []xorCons
+	for i := 0; i < m; i++ {
+		k := 2 + rng.Intn(7)
+		seen := make(map[z.Var]bool)
+		var vs []z.Var
+		for len(vs) < k {
+			v := z.Var(rng.Intn(n) + 1)
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			vs = append(vs, v)
+		}
+		xs = append(xs, xorCons{vs: vs, rhs: rng.Intn(2) == 0})
+	}
+	return xs
+}
+
+// addXors adds xs to s, with some literals negated.
+func addXors(rng *rand.Rand, s *S, xs []xorCons) {
+	for _, x := range xs {
+		rhs := x.rhs
+		ms := make([]z.Lit, len(x.vs))
+		for i, v := range x.vs {
+			ms[i] = v.Pos()
+			if rng.Intn(2) == 0 {
+				ms[i] = v.Neg()
+				rhs = !rhs
+			}
+		}
+		s.AddXor(ms, rhs)
+	}
+}
+
+func checkXors(t *testing.T, s *S, xs []xorCons) {
+	for _, x := range xs {
+		p := false
+		for _, v := range x.vs {
+			if s.Value(v.Pos()) {
+				p = !p
+			}
+		}
+		if p != x.rhs {
+			t.Fatalf("model does not satisfy xor %v = %t", x.vs, x.rhs)
+		}
+	}
+}
+
+func TestXor(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	st := NewStats()
+	sats := 0
+	for i := 0; i < 40; i++ {
+		cnf := elimCnf(rng, 80, 150+rng.Intn(100))
+		xs := randXors(rng, 80, 10+rng.Intn(30))
+		s := NewS()
+		addCnf(s, cnf)
+		addXors(rng, s, xs)
+		o := NewS()
+		addCnf(o, cnf)
+		for _, x := range xs {
+			o.addXorClauses(x.vs, x.rhs)
+		}
+		r := s.Solve()
+		if r != o.Solve() {
+			t.Fatalf("xor result %d", r)
+		}
+		if r == 1 {
+			sats++
+			checkCnf(t, s, cnf)
+			checkXors(t, s, xs)
+		}
+		s.ReadStats(st)
+	}
+	if st.XorProps == 0 || st.XorConflicts == 0 {
+		t.Errorf("no xor propagation: %d/%d", st.XorProps, st.XorConflicts)
+	}
+	if sats == 0 {
+		t.Errorf("no sat instances")
+	}
+}
+
+func TestXorIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(17))
+	for i := 0; i < 20; i++ {
+		cnf := elimCnf(rng, 60, 100)
+		xs := randXors(rng, 60, 20)
+		s := NewS()
+		addCnf(s, cnf)
+		addXors(rng, s, xs)
+		for j := 0; j < 6; j++ {
+			lit := func() z.Lit {
+				m := z.Var(rng.Intn(60) + 1).Pos()
+				if rng.Intn(2) == 0 {
+					return m.Not()
+				}
+				return m
+			}
+			a, b := lit(), lit()
+			o := NewS()
+			addCnf(o, cnf)
+			for _, x := range xs {
+				o.addXorClauses(x.vs, x.rhs)
+			}
+			o.Assume(a, b)
+			want := o.Solve()
+			s.Assume(a)
+			if r, _ := s.Test(nil); r == -1 {
+				if want != -1 {
+					t.Fatalf("test unsat under %s", a)
+				}
+				s.Untest()
+				continue
+			}
+			s.Assume(b)
+			r := s.Solve()
+			if r != want {
+				t.Fatalf("incremental xor result %d", r)
+			}
+			if r == 1 {
+				checkCnf(t, s, cnf)
+				checkXors(t, s, xs)
+				if !s.Value(a) || !s.Value(b) {
+					t.Fatalf("assumption false")
+				}
+			}
+			s.Untest()
+			if j == 3 {
+				// more xors between solves.
+				more := randXors(rng, 60, 3)
+				addXors(rng, s, more)
+				xs = append(xs, more...)
+			}
+		}
+	}
+}
+
+func TestXorGauss(t *testing.T) {
+	// the sum of the xors is 0 = 1, which only elimination finds
+	// without search.
+	s := NewS()
+	a, b, c := z.Var(1), z.Var(2), z.Var(3)
+	s.AddXor([]z.Lit{a.Pos(), b.Pos()}, true)
+	s.AddXor([]z.Lit{b.Pos(), c.Pos()}, true)
+	s.AddXor([]z.Lit{a.Pos(), c.Pos()}, true)
+	if s.Solve() != -1 {
+		t.Errorf("sat")
+	}
+	st := NewStats()
+	s.ReadStats(st)
+	if st.Conflicts != 0 {
+		t.Errorf("conflicts: %d", st.Conflicts)
+	}
+
+	// a chain of n xors over n+1 variables, implying the xor of the ends.
+	s = NewS()
+	n := 50
+	for i := 1; i <= n; i++ {
+		s.AddXor([]z.Lit{z.Var(i).Pos(), z.Var(i + 1).Pos()}, i%2 == 0)
+	}
+	s.Assume(z.Var(1).Pos())
+	if s.Solve() != 1 {
+		t.Fatalf("chain unsat")
+	}
+	// n/2 of the xors have rhs true.
+	end := z.Var(n + 1).Pos()
+	if n/2%2 == 1 {
+		end = end.Not()
+	}
+	if !s.Value(end) {
+		t.Errorf("wrong end of chain")
+	}
+	s.Assume(z.Var(1).Pos(), end.Not())
+	if s.Solve() != -1 {
+		t.Errorf("chain sat with wrong end")
+	}
+}
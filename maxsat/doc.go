@@ -0,0 +1,27 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+// Package maxsat solves weighted partial MaxSAT problems.
+//
+// A weighted partial MaxSAT problem consists of hard clauses, which must be
+// satisfied, and soft clauses with positive weights, which may be violated
+// at a cost of their weight.  A solution is a model of the hard clauses
+// whose cost, the sum of the weights of the soft clauses it violates, is
+// minimal.  Many optimisation problems, such as minimising the number of
+// packages to replace or choosing the most valuable consistent set of
+// requirements, can be stated directly in this form.
+//
+// Package maxsat uses a core guided algorithm in the style of OLL and RC2.
+// The soft clauses are assumed true and solved.  When the result is unsat,
+// the failed assumptions given by Why form a core, a set of soft clauses of
+// which at least one must be violated, so the minimum weight of the core is
+// added to a lower bound of the cost.  The core is then relaxed by an
+// incremental totalizer, a unary counter of its violated soft clauses,
+// whose outputs become new soft constraints allowing successively more
+// violations.  When the result is sat, the model is optimal.
+//
+// The soft clauses are solved by decreasing strata of weights, so that
+// heavy soft clauses are considered first.  Each model found on the way
+// gives an upper bound of the cost, so that solving may be interrupted
+// and still give the best model found so far.
+package maxsat
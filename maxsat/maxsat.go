@@ -0,0 +1,343 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package maxsat
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/z"
+)
+
+// S solves weighted partial MaxSAT problems.
+//
+// Hard clauses are added with Add, as for other solvers, and soft clauses
+// with AddSoft.  The relaxation variables of S are allocated above every
+// variable of the clauses added before solving, so variables introduced
+// afterwards should be obtained from Lit.
+//
+// Typical usage is
+//
+//  s := maxsat.New()
+//  // add hard clauses with s.Add and soft clauses with s.AddSoft
+//  switch s.Solve() {
+//  case 1:
+//    // s.Cost() is optimal, and s.Value gives the optimal model.
+//  case -1:
+//    // the hard clauses are unsat.
+//  }
+//
+type S struct {
+	// Improved, if not nil, is called with the cost of each model found
+	// while solving which is better than the models found before it.
+	Improved func(cost int64)
+
+	g      *gini.Gini
+	top    z.Var    // maximum variable added or allocated
+	cls    []clause // soft clauses
+	nAdded int      // number of soft clauses with assumption literals
+	softs  []soft
+	idx    map[z.Lit]int // index of softs by literal
+	base   int64         // weight of empty soft clauses
+	lb     int64
+	ub     int64  // cost of vals, or -1 if there is none
+	vals   []bool // best model by variable
+	res    int
+	cores  int
+	as     []z.Lit
+	core   []z.Lit
+}
+
+// clause is a soft clause.
+type clause struct {
+	ms []z.Lit
+	w  int64
+}
+
+// soft is a soft literal, an assumption which costs w when violated.
+type soft struct {
+	m z.Lit
+	w int64
+	t *totalizer // if not nil, m is t.outs[k].Not()
+	k int
+}
+
+// New creates a new MaxSAT solver.
+func New() *S {
+	return &S{
+		g:   gini.New(),
+		idx: make(map[z.Lit]int),
+		ub:  -1}
+}
+
+// Lit returns a new variable, above all variables added to s, as a
+// positive literal.
+func (s *S) Lit() z.Lit {
+	return s.lit()
+}
+
+// Add adds a literal to the current hard clause, or ends it when m is 0,
+// conforming to inter.Adder.  Hard constraints coded by a circuit of
+// package logic may hence be added with ToCnf.
+func (s *S) Add(m z.Lit) {
+	s.see(m)
+	s.g.Add(m)
+	if m == z.LitNull {
+		s.reset()
+	}
+}
+
+// AddSoft adds the soft clause ms with weight w, which must not be
+// negative.  A model which does not satisfy ms costs w.
+func (s *S) AddSoft(ms []z.Lit, w int64) {
+	if w < 0 {
+		panic("negative weight")
+	}
+	if w == 0 {
+		return
+	}
+	if len(ms) == 0 {
+		s.base += w
+		s.lb += w
+		s.reset()
+		return
+	}
+	for _, m := range ms {
+		s.see(m)
+	}
+	s.cls = append(s.cls, clause{ms: append([]z.Lit(nil), ms...), w: w})
+	s.reset()
+}
+
+// Solve solves s, returning 1 if an optimal model is found and -1 if the
+// hard clauses are unsat.
+func (s *S) Solve() int {
+	return s.SolveContext(context.Background())
+}
+
+// Try solves s with a timeout, returning 1 if an optimal model is found,
+// -1 if the hard clauses are unsat and 0 if the timeout expires first.
+func (s *S) Try(dur time.Duration) int {
+	ctx, cancel := context.WithTimeout(context.Background(), dur)
+	defer cancel()
+	return s.SolveContext(ctx)
+}
+
+// SolveContext solves s until ctx is done.  SolveContext returns 1 if an
+// optimal model is found, -1 if the hard clauses are unsat and 0 if ctx is
+// done first, in which case the best model found so far, if any, is
+// available and its cost is an upper bound of the optimum.
+//
+// Clauses may be added and s solved again afterwards.
+func (s *S) SolveContext(ctx context.Context) int {
+	if s.res != 0 {
+		return s.res
+	}
+	s.addPending()
+	g := s.g
+	if s.ub < 0 {
+		switch g.SolveContext(ctx) {
+		case 0:
+			return 0
+		case -1:
+			s.res = -1
+			return -1
+		}
+		s.improve()
+	}
+	// solve by strata of decreasing weights.
+	thr := s.below(-1)
+	for s.ub != s.lb {
+		s.as = s.as[:0]
+		for i := range s.softs {
+			if w := s.softs[i].w; w > 0 && w >= thr {
+				s.as = append(s.as, s.softs[i].m)
+			}
+		}
+		g.Assume(s.as...)
+		switch g.SolveContext(ctx) {
+		case 0:
+			return 0
+		case 1:
+			s.improve()
+			thr = s.below(thr)
+			if thr == 0 {
+				// every soft literal is satisfied, so the model
+				// costs the lower bound.
+				s.lb = s.ub
+			}
+		case -1:
+			s.core = g.Why(s.core[:0])
+			if len(s.core) == 0 {
+				// the hard clauses have become unsat.
+				s.res = -1
+				return -1
+			}
+			s.relax(s.core)
+		}
+	}
+	s.res = 1
+	return 1
+}
+
+// Cost returns the cost of the best model found, or -1 if no model has been
+// found.
+func (s *S) Cost() int64 {
+	return s.ub
+}
+
+// LowerBound returns a lower bound of the cost of the optimal model.
+func (s *S) LowerBound() int64 {
+	return s.lb
+}
+
+// UpperBound returns an upper bound of the cost of the optimal model, the
+// cost of the best model found, or -1 if no model has been found.
+func (s *S) UpperBound() int64 {
+	return s.ub
+}
+
+// Cores returns the number of cores relaxed so far.
+func (s *S) Cores() int {
+	return s.cores
+}
+
+// Value returns the value of m in the best model found.
+func (s *S) Value(m z.Lit) bool {
+	v := m.Var()
+	if int(v) >= len(s.vals) {
+		return !m.IsPos()
+	}
+	return s.vals[v] == m.IsPos()
+}
+
+// see records the variable of m as used.
+func (s *S) see(m z.Lit) {
+	if v := m.Var(); v > s.top {
+		s.top = v
+	}
+}
+
+// lit allocates a new variable.
+func (s *S) lit() z.Lit {
+	s.top++
+	return s.top.Pos()
+}
+
+// reset forgets the result and the best model after adding constraints.
+// The lower bound remains valid, since cores of the problem remain cores
+// when adding constraints.
+func (s *S) reset() {
+	if s.res == -1 {
+		return
+	}
+	s.res = 0
+	s.ub = -1
+	s.vals = s.vals[:0]
+}
+
+// addPending adds assumption literals for the soft clauses added since the
+// last solve.
+func (s *S) addPending() {
+	for _, c := range s.cls[s.nAdded:] {
+		if len(c.ms) == 1 {
+			s.addSoft(c.ms[0], c.w, nil, 0)
+			continue
+		}
+		a := s.lit()
+		for _, m := range c.ms {
+			s.g.Add(m)
+		}
+		s.g.Add(a.Not())
+		s.g.Add(0)
+		s.addSoft(a, c.w, nil, 0)
+	}
+	s.nAdded = len(s.cls)
+}
+
+// addSoft adds weight w to the soft literal m.
+func (s *S) addSoft(m z.Lit, w int64, t *totalizer, k int) {
+	if i, ok := s.idx[m]; ok {
+		s.softs[i].w += w
+		return
+	}
+	s.idx[m] = len(s.softs)
+	s.softs = append(s.softs, soft{m: m, w: w, t: t, k: k})
+}
+
+// below returns the greatest positive weight of a soft literal which is
+// less than thr, or any if thr is negative, or 0 if there is none.
+func (s *S) below(thr int64) int64 {
+	res := int64(0)
+	for i := range s.softs {
+		w := s.softs[i].w
+		if w > res && (thr < 0 || w < thr) {
+			res = w
+		}
+	}
+	return res
+}
+
+// relax relaxes the core of soft literals, so that one of them may be
+// violated at the cost of its minimum weight.
+func (s *S) relax(core []z.Lit) {
+	s.cores++
+	wmin := int64(-1)
+	for _, m := range core {
+		if w := s.softs[s.idx[m]].w; wmin < 0 || w < wmin {
+			wmin = w
+		}
+	}
+	s.lb += wmin
+	ms := make([]z.Lit, len(core))
+	for i, m := range core {
+		j := s.idx[m]
+		s.softs[j].w -= wmin
+		ms[i] = m.Not()
+		sf := s.softs[j]
+		if sf.t != nil && sf.k+1 < sf.t.n {
+			// allow one more violation of the inputs of sf.t.
+			sf.t.extend(s, sf.k+2)
+			s.addSoft(sf.t.outs[sf.k+1].Not(), wmin, sf.t, sf.k+1)
+		}
+	}
+	if len(ms) == 1 {
+		return
+	}
+	// at least one of the core is violated, allow exactly one.
+	t := newTotalizer(ms)
+	t.extend(s, 2)
+	s.addSoft(t.outs[1].Not(), wmin, t, 1)
+}
+
+// improve records the model of the last solve if it is better than the
+// best model so far.
+func (s *S) improve() {
+	c := s.base
+	for _, cl := range s.cls {
+		sat := false
+		for _, m := range cl.ms {
+			if s.g.Value(m) {
+				sat = true
+				break
+			}
+		}
+		if !sat {
+			c += cl.w
+		}
+	}
+	if s.ub >= 0 && c >= s.ub {
+		return
+	}
+	s.ub = c
+	n := s.g.MaxVar()
+	s.vals = s.vals[:0]
+	for v := z.Var(0); v <= n; v++ {
+		s.vals = append(s.vals, v > 0 && s.g.Value(v.Pos()))
+	}
+	if s.Improved != nil {
+		s.Improved(c)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package maxsat
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/z"
+)
+
+// inst is a random weighted partial MaxSAT instance over n variables.
+type inst struct {
+	n     int
+	hards [][]z.Lit
+	softs []clause
+}
+
+func randClause(rng *rand.Rand, n, k int) []z.Lit {
+	ms := make([]z.Lit, k)
+	for i := range ms {
+		ms[i] = z.Var(rng.Intn(n) + 1).Pos()
+		if rng.Intn(2) == 0 {
+			ms[i] = ms[i].Not()
+		}
+	}
+	return ms
+}
+
+func randInst(rng *rand.Rand, n, nHard, nSoft int, maxW int64) *inst {
+	p := &inst{n: n}
+	for i := 0; i < nHard; i++ {
+		p.hards = append(p.hards, randClause(rng, n, 3))
+	}
+	for i := 0; i < nSoft; i++ {
+		c := clause{
+			ms: randClause(rng, n, rng.Intn(3)+1),
+			w:  rng.Int63n(maxW) + 1}
+		p.softs = append(p.softs, c)
+	}
+	return p
+}
+
+func (p *inst) add(s *S) {
+	for _, c := range p.hards {
+		for _, m := range c {
+			s.Add(m)
+		}
+		s.Add(0)
+	}
+	for _, c := range p.softs {
+		s.AddSoft(c.ms, c.w)
+	}
+}
+
+// cost returns the cost of the model value, or -1 if it violates a hard
+// clause.
+func (p *inst) cost(value func(z.Lit) bool) int64 {
+	sat := func(ms []z.Lit) bool {
+		for _, m := range ms {
+			if value(m) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range p.hards {
+		if !sat(c) {
+			return -1
+		}
+	}
+	res := int64(0)
+	for _, c := range p.softs {
+		if !sat(c.ms) {
+			res += c.w
+		}
+	}
+	return res
+}
+
+// brute returns the optimal cost of p, or -1 if its hard clauses are unsat.
+func (p *inst) brute() int64 {
+	best := int64(-1)
+	for b := 0; b < 1<<uint(p.n); b++ {
+		c := p.cost(func(m z.Lit) bool {
+			return (b&(1<<uint(m.Var()-1)) != 0) == m.IsPos()
+		})
+		if c >= 0 && (best < 0 || c < best) {
+			best = c
+		}
+	}
+	return best
+}
+
+func TestMaxSat(t *testing.T) {
+	rng := rand.New(rand.NewSource(17))
+	cores, unsat := 0, 0
+	for i := 0; i < 200; i++ {
+		maxW := int64(1)
+		if i%2 == 1 {
+			maxW = 10
+		}
+		p := randInst(rng, 8, rng.Intn(30), 5+rng.Intn(25), maxW)
+		exp := p.brute()
+		s := New()
+		p.add(s)
+		ub := int64(-1)
+		s.Improved = func(c int64) {
+			if ub >= 0 && c >= ub {
+				t.Errorf("improved from %d to %d", ub, c)
+			}
+			ub = c
+		}
+		res := s.Solve()
+		if exp < 0 {
+			if res != -1 {
+				t.Errorf("%d: unsat hard clauses gave %d", i, res)
+			}
+			unsat++
+			continue
+		}
+		if res != 1 {
+			t.Fatalf("%d: result %d", i, res)
+		}
+		if s.Cost() != exp || s.LowerBound() != exp || ub != exp {
+			t.Errorf("%d: cost %d lower bound %d improved %d expected %d", i, s.Cost(), s.LowerBound(), ub, exp)
+		}
+		if c := p.cost(s.Value); c != exp {
+			t.Errorf("%d: model cost %d expected %d", i, c, exp)
+		}
+		cores += s.Cores()
+	}
+	if cores == 0 || unsat == 0 {
+		t.Errorf("trivial instances: %d cores, %d unsat", cores, unsat)
+	}
+}
+
+func TestMaxSatIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < 50; i++ {
+		p := randInst(rng, 8, 5, 20, 5)
+		s := New()
+		p.add(s)
+		for j := 0; j < 4; j++ {
+			exp := p.brute()
+			res := s.Solve()
+			if exp < 0 {
+				if res != -1 {
+					t.Errorf("%d/%d: unsat hard clauses gave %d", i, j, res)
+				}
+				break
+			}
+			if res != 1 || s.Cost() != exp {
+				t.Fatalf("%d/%d: result %d cost %d expected %d", i, j, res, s.Cost(), exp)
+			}
+			if c := p.cost(s.Value); c != exp {
+				t.Errorf("%d/%d: model cost %d expected %d", i, j, c, exp)
+			}
+			q := randInst(rng, 8, 2, 5, 5)
+			q.add(s)
+			p.hards = append(p.hards, q.hards...)
+			p.softs = append(p.softs, q.softs...)
+		}
+	}
+}
+
+func TestMaxSatEdges(t *testing.T) {
+	a, b := z.Var(1).Pos(), z.Var(2).Pos()
+	s := New()
+	if s.Solve() != 1 || s.Cost() != 0 {
+		t.Errorf("empty problem cost %d", s.Cost())
+	}
+	s.AddSoft(nil, 3)
+	s.AddSoft([]z.Lit{a}, 0)
+	s.AddSoft([]z.Lit{a}, 2)
+	s.AddSoft([]z.Lit{a}, 2)
+	s.AddSoft([]z.Lit{a.Not()}, 5)
+	if s.Solve() != 1 || s.Cost() != 7 || s.Value(a) {
+		t.Errorf("cost %d a %t", s.Cost(), s.Value(a))
+	}
+	if s.Value(b) || !s.Value(b.Not()) {
+		t.Errorf("unused variable true")
+	}
+	s.Add(a)
+	s.Add(0)
+	if s.Cost() != -1 {
+		t.Errorf("model kept after adding a clause")
+	}
+	if s.Solve() != 1 || s.Cost() != 8 || !s.Value(a) {
+		t.Errorf("cost %d a %t", s.Cost(), s.Value(a))
+	}
+	s.Add(a.Not())
+	s.Add(0)
+	if s.Solve() != -1 || s.Solve() != -1 {
+		t.Errorf("unsat hard clauses")
+	}
+}
+
+func TestMaxSatTimeout(t *testing.T) {
+	s := New()
+	gen.Php(s, 11, 10)
+	a := s.Lit()
+	s.AddSoft([]z.Lit{a}, 1)
+	if res := s.Try(50 * time.Millisecond); res != 0 {
+		t.Errorf("hard php result %d", res)
+	}
+	if s.Cost() != -1 || s.LowerBound() != 0 {
+		t.Errorf("cost %d lower bound %d", s.Cost(), s.LowerBound())
+	}
+}
+
+func ExampleS() {
+	// choose packages of which the first two conflict, minimising the
+	// number of packages replaced.
+	s := New()
+	pkgs := []z.Lit{z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos(), z.Var(4).Pos()}
+	repls := []z.Lit{z.Var(5).Pos(), z.Var(6).Pos(), z.Var(7).Pos(), z.Var(8).Pos()}
+	s.Add(pkgs[0].Not())
+	s.Add(pkgs[1].Not())
+	s.Add(0)
+	for i, p := range pkgs {
+		// a package is kept unless it is replaced.
+		s.Add(p)
+		s.Add(repls[i])
+		s.Add(0)
+		s.AddSoft([]z.Lit{repls[i].Not()}, 1)
+	}
+	if s.Solve() == 1 {
+		fmt.Println(s.Cost())
+	}
+	// Output: 1
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package maxsat
+
+import "github.com/go-air/gini/z"
+
+// totalizer is an incremental totalizer, a binary tree of unary counters
+// of its input literals.  outs[i] is implied by at least i+1 of the
+// inputs of the node being true.  Only this direction is coded, which
+// suffices to bound the number of true inputs from above by assuming
+// outputs false.
+//
+// The outputs are coded lazily, up to a bound which may be increased by
+// extend.
+type totalizer struct {
+	l, r *totalizer
+	n    int // number of inputs
+	outs []z.Lit
+}
+
+// newTotalizer creates a totalizer of ms, which should not be empty,
+// without any outputs of its inner nodes.
+func newTotalizer(ms []z.Lit) *totalizer {
+	if len(ms) == 1 {
+		return &totalizer{n: 1, outs: []z.Lit{ms[0]}}
+	}
+	h := len(ms) / 2
+	return &totalizer{
+		l: newTotalizer(ms[:h]),
+		r: newTotalizer(ms[h:]),
+		n: len(ms)}
+}
+
+// extend codes the outputs of t up to k, or up to the number of inputs of
+// t if it is less, adding the clauses to s.
+func (t *totalizer) extend(s *S, k int) {
+	if k > t.n {
+		k = t.n
+	}
+	old := len(t.outs)
+	if old >= k {
+		return
+	}
+	t.l.extend(s, k)
+	t.r.extend(s, k)
+	for len(t.outs) < k {
+		t.outs = append(t.outs, s.lit())
+	}
+	g := s.g
+	for i := 0; i <= len(t.l.outs); i++ {
+		for j := 0; j <= len(t.r.outs); j++ {
+			c := i + j
+			if c <= old || c > k {
+				continue
+			}
+			if i > 0 {
+				g.Add(t.l.outs[i-1].Not())
+			}
+			if j > 0 {
+				g.Add(t.r.outs[j-1].Not())
+			}
+			g.Add(t.outs[c-1])
+			g.Add(0)
+		}
+	}
+}
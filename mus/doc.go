@@ -0,0 +1,26 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+// Package mus extracts minimal unsatisfiable subsets (MUSes) and minimal
+// correction subsets (MCSes) of assumptions.
+//
+// The failed assumptions given by Why after unsat are a subset of the
+// assumptions which is unsat, but they are not in general minimal.  A MUS
+// is a subset from which no assumption can be removed without it becoming
+// sat, so that each of its assumptions is necessary.  When assumptions
+// select constraints, such as requirements of a configuration, a MUS gives
+// a small explanation of why they conflict.
+//
+// An Extractor finds a MUS by deletion, removing each assumption in turn
+// and solving, with two refinements.  When removing an assumption leaves
+// the rest unsat, the rest is narrowed to the failed assumptions (clause
+// set refinement).  When it leaves the rest sat, the model is modified
+// locally to show other assumptions necessary without solving (model
+// rotation).  Each solve can be limited by a budget, so that extraction
+// remains usable on large problems.
+//
+// Dually, a minimal correction subset (MCS) is a subset of the
+// assumptions whose removal leaves the rest sat, and which is minimal with
+// this property.  MCSs enumerates MCSes, and MUSes enumerates all MUSes as
+// the minimal hitting sets of the MCSes.
+package mus
@@ -0,0 +1,207 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package mus
+
+import (
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+// ActivatableS is a solver with activation literals.
+type ActivatableS interface {
+	inter.S
+	inter.Activatable
+}
+
+// MCSs enumerates the minimal correction subsets (MCSes) of assumptions.
+//
+// An MCS of assumptions ms is a subset of ms whose removal leaves the rest
+// of ms sat, such that removing any smaller subset of it leaves the rest
+// unsat.  In other words, the rest is a maximal satisfiable subset.  When
+// assumptions select requirements, the MCSes are the minimal sets of
+// requirements which may be dropped to make the rest feasible.
+//
+// Each MCS is found by growing a satisfiable subset of ms to a maximal
+// one, and then blocked by a clause of the solver requiring one of its
+// assumptions.  The blocking clauses are activated by one activation
+// literal, which Close deactivates.  The variables of ms should be at most
+// the MaxVar of the solver, so as not to be confused with the activation
+// literal.
+//
+// Typical usage is
+//
+//  e := NewMCSs(s, ms)
+//  defer e.Close()
+//  for e.Next() {
+//    mcs := e.MCS()
+//    ...
+//  }
+//
+type MCSs struct {
+	Budget
+
+	s     ActivatableS
+	ms    []z.Lit
+	act   z.Lit // activates the blocking clauses
+	mcs   []z.Lit
+	as    []z.Lit
+	done  bool
+	exact bool
+	res   int
+}
+
+// NewMCSs creates an enumerator of the MCSes of ms in s.
+func NewMCSs(s ActivatableS, ms []z.Lit) *MCSs {
+	return &MCSs{
+		s:     s,
+		ms:    uniq(ms),
+		exact: true}
+}
+
+// Next finds the next MCS, returning whether it found one.  Next returns
+// false once all MCSes are found, or when the budget is exhausted, in which
+// case Res is 0.
+func (e *MCSs) Next() bool {
+	if e.done {
+		return false
+	}
+	e.as = e.as[:0]
+	if e.act != z.LitNull {
+		e.as = append(e.as, e.act)
+	}
+	switch e.solve(e.s, e.as) {
+	case -1:
+		e.done = true
+		e.res = -1
+		return false
+	case 0:
+		e.done = true
+		return false
+	}
+	pre := e.as
+	var ok bool
+	e.mcs, e.as, ok = e.grow(e.s, pre, e.ms, e.mcs[:0])
+	if !ok {
+		e.exact = false
+	}
+	if len(e.mcs) == 0 {
+		// ms is sat, and its only MCS is empty.
+		e.done = true
+		e.res = -1
+		return true
+	}
+	if !e.blockable() {
+		// every assumption of the MCS is false by itself, so the
+		// blocking clause would be empty, and there are no more MCSes.
+		e.done = true
+		e.res = -1
+		return true
+	}
+	if e.act == z.LitNull {
+		e.act = e.s.ActivationLit()
+	}
+	for _, m := range e.mcs {
+		e.s.Add(m)
+	}
+	e.s.ActivateWith(e.act)
+	return true
+}
+
+// blockable returns whether some assumption of the MCS is not false by
+// unit propagation, so that the blocking clause is not empty at level 0.
+func (e *MCSs) blockable() bool {
+	for _, m := range e.mcs {
+		e.s.Assume(m)
+		r, _ := e.s.Test(nil)
+		e.s.Untest()
+		if r != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// MCS returns the MCS found by the last call to Next, in the order of the
+// assumptions.  The result is valid until the next call to Next.
+func (e *MCSs) MCS() []z.Lit {
+	return e.mcs
+}
+
+// Res returns -1 if e has found all MCSes and 0 otherwise.
+func (e *MCSs) Res() int {
+	return e.res
+}
+
+// Exact returns whether the MCSes found so far are minimal, which they
+// may not be if the budget was exhausted while growing a satisfiable
+// subset.
+func (e *MCSs) Exact() bool {
+	return e.exact
+}
+
+// Close removes the blocking clauses from the solver.  e should not be
+// used afterwards.
+func (e *MCSs) Close() {
+	if e.act != z.LitNull {
+		e.s.Deactivate(e.act)
+		e.act = z.LitNull
+	}
+	e.done = true
+}
+
+// grow grows the subset of ms true in the model of the last solve of s
+// to a maximal satisfiable subset under the assumptions pre, appending its
+// complement in ms, an MCS, to dst.  The returned bool is false if the
+// budget was exhausted, in which case the complement may not be minimal.
+// as is a buffer for assumptions.
+func (b *Budget) grow(s inter.S, pre, ms, dst []z.Lit) (mcs, as []z.Lit, ok bool) {
+	sat := make([]bool, len(ms))
+	for i, m := range ms {
+		sat[i] = s.Value(m)
+	}
+	n := len(pre)
+	as = pre
+	ok = true
+	for i, m := range ms {
+		if sat[i] {
+			continue
+		}
+		as = as[:n]
+		for j, o := range ms {
+			if sat[j] {
+				as = append(as, o)
+			}
+		}
+		as = append(as, m)
+		switch b.solve(s, as) {
+		case 1:
+			for j, o := range ms {
+				if !sat[j] && s.Value(o) {
+					sat[j] = true
+				}
+			}
+		case 0:
+			ok = false
+		}
+	}
+	for i, m := range ms {
+		if !sat[i] {
+			dst = append(dst, m)
+		}
+	}
+	return dst, as[:n], ok
+}
+
+// uniq returns a copy of ms without duplicates.
+func uniq(ms []z.Lit) []z.Lit {
+	res := make([]z.Lit, 0, len(ms))
+	seen := make(map[z.Lit]bool, len(ms))
+	for _, m := range ms {
+		if !seen[m] {
+			seen[m] = true
+			res = append(res, m)
+		}
+	}
+	return res
+}
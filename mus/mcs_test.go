@@ -0,0 +1,159 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package mus
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/z"
+)
+
+// brute returns the MUSes and MCSes of ss in g by solving under every
+// subset, as sorted strings of subsets.
+func brute(g *gini.Gini, ss []z.Lit) (muses, mcss []string) {
+	n := len(ss)
+	sat := make([]bool, 1<<uint(n))
+	for b := range sat {
+		for i, m := range ss {
+			if b&(1<<uint(i)) != 0 {
+				g.Assume(m)
+			}
+		}
+		sat[b] = g.Solve() == 1
+	}
+	str := func(b int) string {
+		var ms []z.Lit
+		for i, m := range ss {
+			if b&(1<<uint(i)) != 0 {
+				ms = append(ms, m)
+			}
+		}
+		return fmt.Sprint(ms)
+	}
+	all := 1<<uint(n) - 1
+	for b := range sat {
+		isMUS, isMSS := !sat[b], sat[b]
+		for i := 0; i < n; i++ {
+			c := b ^ (1 << uint(i))
+			if b&(1<<uint(i)) != 0 && !sat[b] && !sat[c] {
+				isMUS = false
+			}
+			if b&(1<<uint(i)) == 0 && sat[b] && sat[c] {
+				isMSS = false
+			}
+		}
+		if isMUS {
+			muses = append(muses, str(b))
+		}
+		if isMSS {
+			mcss = append(mcss, str(all^b))
+		}
+	}
+	sort.Strings(muses)
+	sort.Strings(mcss)
+	return
+}
+
+func TestMCSsMUSes(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	multi := 0
+	for i := 0; i < 20; i++ {
+		g := gini.New()
+		ss := randSelected(g, rng, 3, 11, 2)
+		muses, mcss := brute(g, ss)
+
+		e := NewMCSs(g, ss)
+		var got []string
+		for e.Next() {
+			got = append(got, fmt.Sprint(e.MCS()))
+		}
+		if e.Res() != -1 || !e.Exact() {
+			t.Errorf("mcs enumeration incomplete")
+		}
+		e.Close()
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(mcss) {
+			t.Errorf("mcses\n%v\nexpected\n%v", got, mcss)
+		}
+
+		// the blocking clauses are gone.
+		f := NewMUSes(g, ss)
+		got = got[:0]
+		for f.Next() {
+			got = append(got, fmt.Sprint(f.MUS()))
+		}
+		if f.Res() != -1 {
+			t.Errorf("mus enumeration incomplete")
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(muses) {
+			t.Errorf("muses\n%v\nexpected\n%v", got, muses)
+		}
+		if len(muses) > 1 {
+			multi++
+		}
+	}
+	if multi == 0 {
+		t.Errorf("no instances with several muses")
+	}
+}
+
+func TestMCSsEdges(t *testing.T) {
+	a, b := z.Var(1).Pos(), z.Var(2).Pos()
+	// a is false by itself.
+	g := gini.New()
+	g.Add(a.Not())
+	g.Add(0)
+	g.Add(b)
+	g.Add(0)
+	e := NewMCSs(g, []z.Lit{a, b, a})
+	var got []string
+	for e.Next() {
+		got = append(got, fmt.Sprint(e.MCS()))
+	}
+	e.Close()
+	if fmt.Sprint(got) != fmt.Sprint([]string{fmt.Sprint([]z.Lit{a})}) {
+		t.Errorf("mcses %v", got)
+	}
+	f := NewMUSes(g, []z.Lit{a, b})
+	got = got[:0]
+	for f.Next() {
+		got = append(got, fmt.Sprint(f.MUS()))
+	}
+	if fmt.Sprint(got) != fmt.Sprint([]string{fmt.Sprint([]z.Lit{a})}) {
+		t.Errorf("muses %v", got)
+	}
+
+	// sat assumptions have one empty MCS and no MUS.
+	e = NewMCSs(g, []z.Lit{b})
+	n := 0
+	for e.Next() {
+		if len(e.MCS()) != 0 {
+			t.Errorf("mcs %v", e.MCS())
+		}
+		n++
+	}
+	e.Close()
+	if n != 1 {
+		t.Errorf("%d mcses", n)
+	}
+	if NewMUSes(g, []z.Lit{b}).Next() {
+		t.Errorf("sat mus")
+	}
+
+	// unsat without assumptions has no MCS and one empty MUS.
+	g.Add(b.Not())
+	g.Add(0)
+	if NewMCSs(g, []z.Lit{a, b}).Next() {
+		t.Errorf("unsat mcs")
+	}
+	f = NewMUSes(g, []z.Lit{a, b})
+	if !f.Next() || len(f.MUS()) != 0 || f.Next() {
+		t.Errorf("unsat muses")
+	}
+}
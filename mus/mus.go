@@ -0,0 +1,400 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package mus
+
+import (
+	"time"
+
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+// states of assumption literals during extraction.
+const (
+	none int8 = iota
+	mark      // temporary
+	cand      // not yet known to be necessary
+	crit      // necessary
+)
+
+// Budget limits each solve made by an Extractor or an enumerator.  The
+// zero Budget is no limit.
+type Budget struct {
+	// Conflicts and Props limit each solve if the solver is an
+	// inter.BudgetSolvable.  0 is no limit.
+	Conflicts int64
+	Props     int64
+	// Timeout limits each solve if the solver is not an
+	// inter.BudgetSolvable or has no budget.  0 is no limit.
+	Timeout time.Duration
+}
+
+// solve solves s under the assumptions as within the budget.
+func (b *Budget) solve(s inter.S, as []z.Lit) int {
+	s.Assume(as...)
+	if bs, ok := s.(inter.BudgetSolvable); ok && (b.Conflicts > 0 || b.Props > 0) {
+		return bs.TryBudget(b.Conflicts, b.Props)
+	}
+	if b.Timeout > 0 {
+		return s.Try(b.Timeout)
+	}
+	return s.Solve()
+}
+
+// Extractor extracts MUSes of assumptions of a solver.
+//
+// Model rotation uses the clauses added to the solver through the
+// Extractor, which implements inter.Adder.  Clauses may also be added to
+// the solver directly; rotated models are checked with the solver, but
+// rotation is less effective if the Extractor does not know the clauses.
+type Extractor struct {
+	Budget
+	// Rotate enables model rotation, which is the default.
+	Rotate bool
+
+	// Solves is the number of solves by the last call to MUS, and Rotated
+	// the number of assumptions found necessary by model rotation.
+	Solves  int
+	Rotated int
+
+	s     inter.S
+	cls   []z.Lit // recorded clauses, each terminated by z.LitNull
+	occs  [][]int // starts of recorded clauses by literal
+	nOccs int     // prefix of cls indexed in occs
+	state []int8  // by literal
+	vals  []int8  // model by variable
+	as    []z.Lit
+	why   []z.Lit
+	ns    []z.Lit
+}
+
+// New creates an Extractor of MUSes of assumptions of s.
+func New(s inter.S) *Extractor {
+	return &Extractor{
+		Rotate: true,
+		s:      s}
+}
+
+// Add adds m to the solver, as in inter.Adder, and records it for model
+// rotation.
+func (x *Extractor) Add(m z.Lit) {
+	x.s.Add(m)
+	x.cls = append(x.cls, m)
+}
+
+// MUS returns the result of solving under the assumptions ms and, if it is
+// unsat, a minimal subset of ms which is unsat, in the order of ms.
+//
+// If res is -1, then mus is a MUS.  If res is 0, then either mus is nil
+// and the budget was exhausted before ms was found unsat, or mus is unsat
+// but may not be minimal, as the budget was exhausted while deciding
+// whether some of its assumptions are necessary.  If res is 1, then ms is
+// sat and mus is nil.
+func (x *Extractor) MUS(ms []z.Lit) (res int, mus []z.Lit) {
+	x.Solves, x.Rotated = 0, 0
+	x.grow(ms)
+	res = x.solve(ms)
+	if res != -1 {
+		return res, nil
+	}
+	st := x.state
+	x.why = x.s.Why(x.why[:0])
+	for _, m := range x.why {
+		st[m] = mark
+	}
+	var us, cs []z.Lit
+	for _, m := range ms {
+		if st[m] == mark {
+			st[m] = cand
+			us = append(us, m)
+		}
+	}
+	for _, m := range x.why {
+		if st[m] == mark {
+			st[m] = none
+		}
+	}
+	for len(us) > 0 {
+		m := us[len(us)-1]
+		us = us[:len(us)-1]
+		if st[m] != cand {
+			continue
+		}
+		st[m] = none
+		x.as = append(x.as[:0], cs...)
+		for _, u := range us {
+			if st[u] == cand {
+				x.as = append(x.as, u)
+			}
+		}
+		switch x.solve(x.as) {
+		case -1:
+			// clause set refinement.
+			x.why = x.s.Why(x.why[:0])
+			for _, w := range x.why {
+				if st[w] == cand {
+					st[w] = mark
+				}
+			}
+			j := 0
+			for _, u := range us {
+				switch st[u] {
+				case mark:
+					st[u] = cand
+					us[j] = u
+					j++
+				case cand:
+					st[u] = none
+				}
+			}
+			us = us[:j]
+		case 1:
+			st[m] = crit
+			cs = append(cs, m)
+			if x.Rotate && len(x.cls) != 0 {
+				cs = x.rotate(m, cs)
+			}
+		default:
+			st[m] = crit
+			cs = append(cs, m)
+			res = 0
+		}
+	}
+	for _, m := range ms {
+		if st[m] == crit {
+			mus = append(mus, m)
+			st[m] = none
+		}
+	}
+	return res, mus
+}
+
+// solve solves under the assumptions as within the budget.
+func (x *Extractor) solve(as []z.Lit) int {
+	x.Solves++
+	return x.Budget.solve(x.s, as)
+}
+
+func (x *Extractor) grow(ms []z.Lit) {
+	n := x.s.MaxVar()
+	for _, m := range ms {
+		if m.Var() > n {
+			n = m.Var()
+		}
+	}
+	if w := int(n+1) * 2; w > len(x.state) {
+		st := make([]int8, w)
+		copy(st, x.state)
+		x.state = st
+	}
+}
+
+// rotate applies model rotation from the model of the last solve, which
+// shows m to be necessary, appending the assumptions it finds necessary
+// to cs.
+func (x *Extractor) rotate(m z.Lit, cs []z.Lit) []z.Lit {
+	x.model()
+	x.index()
+	for {
+		n := x.rotation(m)
+		if n == z.LitNull {
+			return cs
+		}
+		x.state[n] = crit
+		cs = append(cs, n)
+		x.Rotated++
+		m = n
+	}
+}
+
+// rotation modifies the model, which satisfies the clauses and falsifies
+// the assumption m only, so that it falsifies the candidate n only
+// instead, and returns n.  If it finds no such n, rotation returns
+// z.LitNull.
+//
+// As in rotation of clause level MUSes, m is satisfied, and if exactly one
+// clause containing m.Not() becomes false, each of its literals is
+// flipped in turn.  If the clauses this falsifies all contain n.Not() for
+// some candidate n, then falsifying n gives the new model.
+func (x *Extractor) rotation(m z.Lit) z.Lit {
+	st := x.state
+	x.set(m)
+	if c := x.onlyFalse(m.Not()); c != -1 {
+		for i := c; x.cls[i] != z.LitNull; i++ {
+			l := x.cls[i]
+			if l == m.Not() || st[l.Not()] != none {
+				continue
+			}
+			x.set(l)
+			if n := x.blame(l.Not()); n != z.LitNull {
+				x.set(n.Not())
+				if x.noneFalse(n) && x.verify() {
+					return n
+				}
+				x.set(n)
+			}
+			x.set(l.Not())
+		}
+	}
+	x.set(m.Not())
+	return z.LitNull
+}
+
+// onlyFalse returns the start of the only false clause containing p, or -1
+// if there are none or several.
+func (x *Extractor) onlyFalse(p z.Lit) int {
+	res := -1
+	for _, c := range x.occs[p] {
+		if !x.isFalse(c) {
+			continue
+		}
+		if res != -1 {
+			return -1
+		}
+		res = c
+	}
+	return res
+}
+
+// blame returns the candidate n such that all false clauses containing p
+// contain n.Not(), or z.LitNull if there is no such candidate or no such
+// clause.
+func (x *Extractor) blame(p z.Lit) z.Lit {
+	st := x.state
+	ns := x.ns[:0]
+	first := true
+	for _, c := range x.occs[p] {
+		if !x.isFalse(c) {
+			continue
+		}
+		if first {
+			for i := c; x.cls[i] != z.LitNull; i++ {
+				if n := x.cls[i].Not(); st[n] == cand {
+					ns = append(ns, n)
+				}
+			}
+			first = false
+			continue
+		}
+		j := 0
+		for _, n := range ns {
+			if x.contains(c, n.Not()) {
+				ns[j] = n
+				j++
+			}
+		}
+		ns = ns[:j]
+	}
+	x.ns = ns
+	if len(ns) == 0 {
+		return z.LitNull
+	}
+	return ns[0]
+}
+
+// noneFalse returns whether no clause containing p is false.
+func (x *Extractor) noneFalse(p z.Lit) bool {
+	for _, c := range x.occs[p] {
+		if x.isFalse(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *Extractor) contains(c int, m z.Lit) bool {
+	for i := c; x.cls[i] != z.LitNull; i++ {
+		if x.cls[i] == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *Extractor) isFalse(c int) bool {
+	for i := c; x.cls[i] != z.LitNull; i++ {
+		if x.value(x.cls[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *Extractor) value(m z.Lit) bool {
+	v := x.vals[m.Var()]
+	return v != 0 && (v == 1) == m.IsPos()
+}
+
+func (x *Extractor) set(m z.Lit) {
+	x.vals[m.Var()] = 1
+	if !m.IsPos() {
+		x.vals[m.Var()] = -1
+	}
+}
+
+// model reads the model of the last solve.
+func (x *Extractor) model() {
+	n := len(x.state) / 2
+	if cap(x.vals) < n {
+		x.vals = make([]int8, n)
+	}
+	x.vals = x.vals[:n]
+	M := x.s.MaxVar()
+	for i := range x.vals {
+		v := z.Var(i)
+		x.vals[i] = 0
+		if v == 0 || v > M {
+			continue
+		}
+		x.vals[i] = -1
+		if x.s.Value(v.Pos()) {
+			x.vals[i] = 1
+		}
+	}
+}
+
+// index records the occurrences of literals in clauses added since the
+// last call.
+func (x *Extractor) index() {
+	if w := len(x.state); w > len(x.occs) {
+		occs := make([][]int, w)
+		copy(occs, x.occs)
+		x.occs = occs
+	}
+	end := len(x.cls)
+	for end > x.nOccs && x.cls[end-1] != z.LitNull {
+		end--
+	}
+	c := x.nOccs
+	for i := x.nOccs; i < end; i++ {
+		m := x.cls[i]
+		if m == z.LitNull {
+			c = i + 1
+			continue
+		}
+		x.occs[m] = append(x.occs[m], c)
+	}
+	x.nOccs = end
+}
+
+// verify checks the model with the solver, which may have clauses unknown
+// to x.  As the model assigns all variables, it satisfies the solver's
+// constraints if testing it does not find a conflict.
+func (x *Extractor) verify() bool {
+	x.as = x.as[:0]
+	for i := 1; i < len(x.vals); i++ {
+		m := z.Var(i).Pos()
+		if x.vals[i] == 0 {
+			continue
+		}
+		if x.vals[i] < 0 {
+			m = m.Not()
+		}
+		x.as = append(x.as, m)
+	}
+	x.s.Assume(x.as...)
+	r, _ := x.s.Test(nil)
+	x.s.Untest()
+	return r != -1
+}
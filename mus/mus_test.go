@@ -0,0 +1,148 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package mus
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+// randSelected adds m random clauses of k literals over n variables to
+// dst, each selected by the assumption of a selector literal, and returns
+// the selectors.
+func randSelected(dst inter.Adder, rng *rand.Rand, n, m, k int) []z.Lit {
+	ss := make([]z.Lit, m)
+	for i := range ss {
+		for j := 0; j < k; j++ {
+			l := z.Var(rng.Intn(n) + 1).Pos()
+			if rng.Intn(2) == 0 {
+				l = l.Not()
+			}
+			dst.Add(l)
+		}
+		ss[i] = z.Var(n + i + 1).Pos()
+		dst.Add(ss[i].Not())
+		dst.Add(0)
+	}
+	return ss
+}
+
+// checkMUS checks that mus is unsat and that each subset without one of
+// its assumptions is sat.
+func checkMUS(t *testing.T, s inter.S, mus []z.Lit) {
+	s.Assume(mus...)
+	if s.Solve() != -1 {
+		t.Errorf("mus %v sat", mus)
+		return
+	}
+	for i := range mus {
+		for j, m := range mus {
+			if j != i {
+				s.Assume(m)
+			}
+		}
+		if s.Solve() != 1 {
+			t.Errorf("mus %v not minimal at %s", mus, mus[i])
+		}
+	}
+}
+
+func TestMUS(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	rotated, unsat := 0, 0
+	for i := 0; i < 30; i++ {
+		g := gini.New()
+		x := New(g)
+		var ss []z.Lit
+		if i%3 == 2 {
+			// clauses unknown to x.
+			ss = randSelected(g, rng, 20, 120, 3)
+		} else {
+			ss = randSelected(x, rng, 20, 120, 3)
+		}
+		x.Rotate = i%3 != 1
+		res, mus := x.MUS(ss)
+		if res == 1 {
+			if mus != nil {
+				t.Errorf("sat with mus %v", mus)
+			}
+			continue
+		}
+		if res != -1 || len(mus) == 0 {
+			t.Fatalf("result %d mus %v", res, mus)
+		}
+		unsat++
+		rotated += x.Rotated
+		if x.Rotated != 0 && !x.Rotate {
+			t.Errorf("rotated without rotation")
+		}
+		checkMUS(t, g, mus)
+	}
+	if unsat == 0 {
+		t.Fatalf("no unsat instances")
+	}
+	if rotated == 0 {
+		t.Errorf("no rotations")
+	}
+}
+
+func TestMUSBudget(t *testing.T) {
+	g := gini.New()
+	gen.Php(g, 9, 8)
+	a := g.Lit()
+	x := New(g)
+	x.Conflicts = 100
+	if res, mus := x.MUS([]z.Lit{a}); res != 0 || mus != nil {
+		t.Errorf("hard php result %d mus %v", res, mus)
+	}
+	x.Conflicts = 0
+	g = gini.New()
+	x = New(g)
+	if res, mus := x.MUS([]z.Lit{g.Lit()}); res != 1 || mus != nil {
+		t.Errorf("empty problem result %d mus %v", res, mus)
+	}
+}
+
+func ExampleExtractor() {
+	// requirements of a configuration, each selected by a literal.
+	g := gini.New()
+	x := New(g)
+	ssd, hdd, laptop, cheap, fast := z.Var(1).Pos(), z.Var(2).Pos(), z.Var(3).Pos(), z.Var(4).Pos(), z.Var(5).Pos()
+	reqs := []struct {
+		name string
+		ms   []z.Lit
+	}{
+		{"one disk", []z.Lit{ssd, hdd}},
+		{"not both disks", []z.Lit{ssd.Not(), hdd.Not()}},
+		{"laptops have no hdd", []z.Lit{laptop.Not(), hdd.Not()}},
+		{"laptop", []z.Lit{laptop}},
+		{"cheap", []z.Lit{cheap}},
+		{"cheap has no ssd", []z.Lit{cheap.Not(), ssd.Not()}},
+		{"fast", []z.Lit{fast}}}
+	sels := make([]z.Lit, len(reqs))
+	for i, r := range reqs {
+		sels[i] = z.Var(6 + i).Pos()
+		for _, m := range r.ms {
+			x.Add(m)
+		}
+		x.Add(sels[i].Not())
+		x.Add(0)
+	}
+	_, mus := x.MUS(sels)
+	for _, m := range mus {
+		fmt.Println(reqs[m.Var()-sels[0].Var()].name)
+	}
+	// Output:
+	// one disk
+	// laptops have no hdd
+	// laptop
+	// cheap
+	// cheap has no ssd
+}
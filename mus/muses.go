@@ -0,0 +1,171 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package mus
+
+import (
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
+)
+
+// MUSes enumerates all the MUSes of assumptions using hitting set duality.
+//
+// The MUSes of assumptions ms are the minimal hitting sets of the MCSes of
+// ms, the minimal subsets of ms which intersect every MCS.  MUSes keeps
+// the correction sets found so far in a separate solver of hitting sets,
+// together with clauses blocking the MUSes found so far.  A minimal
+// hitting set of the correction sets found so far is either unsat, in
+// which case it is a MUS, or sat, in which case it is grown to a new
+// correction set.
+//
+// There can be exponentially many MUSes, so enumerating all of them is
+// only practical for small numbers of assumptions.
+//
+// Typical usage is
+//
+//  e := NewMUSes(s, ms)
+//  for e.Next() {
+//    mus := e.MUS()
+//    ...
+//  }
+//
+type MUSes struct {
+	Budget
+
+	s    inter.S
+	ms   []z.Lit
+	h    *gini.Gini // hitting sets, variable i+1 for ms[i]
+	occs [][]int    // indices of correction sets by index of ms
+	cnts []int      // size of intersection with hitting set by correction set
+	hs   []int      // hitting set, as indices of ms
+	mus  []z.Lit
+	as   []z.Lit
+	done bool
+	res  int
+}
+
+// NewMUSes creates an enumerator of the MUSes of ms in s.
+func NewMUSes(s inter.S, ms []z.Lit) *MUSes {
+	ms = uniq(ms)
+	return &MUSes{
+		s:    s,
+		ms:   ms,
+		h:    gini.New(),
+		occs: make([][]int, len(ms))}
+}
+
+// Next finds the next MUS, returning whether it found one.  Next returns
+// false once all MUSes are found, or when the budget is exhausted, in which
+// case Res is 0.
+func (e *MUSes) Next() bool {
+	h := e.h
+	for !e.done {
+		if h.Solve() == -1 {
+			e.done = true
+			e.res = -1
+			return false
+		}
+		e.hs = e.hs[:0]
+		M := h.MaxVar()
+		for i := range e.ms {
+			if v := z.Var(i + 1); v <= M && h.Value(v.Pos()) {
+				e.hs = append(e.hs, i)
+			}
+		}
+		e.minimize()
+		e.as = e.as[:0]
+		for _, i := range e.hs {
+			e.as = append(e.as, e.ms[i])
+		}
+		switch e.solve(e.s, e.as) {
+		case -1:
+			e.mus = append(e.mus[:0], e.as...)
+			for _, i := range e.hs {
+				h.Add(z.Var(i + 1).Neg())
+			}
+			h.Add(0)
+			return true
+		case 0:
+			e.done = true
+			return false
+		}
+		// grow to a correction set disjoint from the hitting set.  If
+		// the budget is exhausted, it is not minimal, but every MUS
+		// still intersects it.
+		var cs []z.Lit
+		cs, e.as, _ = e.grow(e.s, nil, e.ms, nil)
+		if len(cs) == 0 {
+			// ms is sat.
+			e.done = true
+			e.res = -1
+			return false
+		}
+		e.addCorrection(cs)
+	}
+	return false
+}
+
+// MUS returns the MUS found by the last call to Next, in the order of the
+// assumptions.  The result is valid until the next call to Next.
+func (e *MUSes) MUS() []z.Lit {
+	return e.mus
+}
+
+// Res returns -1 if e has found all MUSes and 0 otherwise.
+func (e *MUSes) Res() int {
+	return e.res
+}
+
+// addCorrection adds the correction set cs, whose literals are in the
+// order of e.ms.
+func (e *MUSes) addCorrection(cs []z.Lit) {
+	k := len(e.cnts)
+	e.cnts = append(e.cnts, 0)
+	j := 0
+	for i, m := range e.ms {
+		if j == len(cs) {
+			break
+		}
+		if m != cs[j] {
+			continue
+		}
+		j++
+		e.occs[i] = append(e.occs[i], k)
+		e.h.Add(z.Var(i + 1).Pos())
+	}
+	e.h.Add(0)
+}
+
+// minimize removes elements of the hitting set while it still hits every
+// correction set.
+func (e *MUSes) minimize() {
+	cnts := e.cnts
+	for k := range cnts {
+		cnts[k] = 0
+	}
+	for _, i := range e.hs {
+		for _, k := range e.occs[i] {
+			cnts[k]++
+		}
+	}
+	j := 0
+	for _, i := range e.hs {
+		needed := false
+		for _, k := range e.occs[i] {
+			if cnts[k] == 1 {
+				needed = true
+				break
+			}
+		}
+		if needed {
+			e.hs[j] = i
+			j++
+			continue
+		}
+		for _, k := range e.occs[i] {
+			cnts[k]--
+		}
+	}
+	e.hs = e.hs[:j]
+}
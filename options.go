@@ -0,0 +1,70 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package gini
+
+import "github.com/go-air/gini/internal/xo"
+
+// RestartPolicy identifies how a Gini schedules restarts.
+type RestartPolicy = xo.RestartPolicy
+
+const (
+	// RestartLuby restarts following the Luby sequence scaled by
+	// Options.RestartFactor conflicts.
+	RestartLuby RestartPolicy = xo.RestartLuby
+	// RestartNone never restarts.
+	RestartNone RestartPolicy = xo.RestartNone
+	// RestartGlucose restarts when the average lbd of recently learnt
+	// clauses exceeds the long term average by Options.RestartMargin,
+	// unless blocked because the trail is larger than its average by
+	// Options.RestartBlock.
+	RestartGlucose RestartPolicy = xo.RestartGlucose
+)
+
+// Mode identifies how a Gini guesses variables and schedules restarts.
+type Mode = xo.Mode
+
+const (
+	// ModeStable guesses the most active variables according to a
+	// VSIDS heap and restarts according to Options.Restarts.
+	ModeStable Mode = xo.ModeStable
+	// ModeFocused guesses the most recently bumped variables according
+	// to a VMTF queue and uses glucose style restarts.
+	ModeFocused Mode = xo.ModeFocused
+	// ModeSwitch starts in focused mode and switches between focused
+	// and stable mode after Options.ModeConflicts conflicts, doubling the
+	// number of conflicts at each switch.
+	ModeSwitch Mode = xo.ModeSwitch
+)
+
+// Rephase identifies how a Gini resets its saved phases when rephasing.
+type Rephase = xo.Rephase
+
+const (
+	// RephaseOriginal resets to the initial phases, which follow the
+	// polarity of literal occurrences in short clauses.
+	RephaseOriginal Rephase = xo.RephaseOriginal
+	// RephaseInverted resets to the inverted initial phases.
+	RephaseInverted Rephase = xo.RephaseInverted
+	// RephaseBest resets to the values of the longest conflict free
+	// trail since the last RephaseBest.
+	RephaseBest Rephase = xo.RephaseBest
+	// RephaseRandom resets to random phases.
+	RephaseRandom Rephase = xo.RephaseRandom
+	// RephaseWalk resets to the result of a local search starting from
+	// the saved phases.
+	RephaseWalk Rephase = xo.RephaseWalk
+)
+
+// Options holds tuning parameters for a Gini, for use with
+// NewWithOptions.  Options is the options type of the underlying solver,
+// whose fields are documented at xo.Options in package
+// github.com/go-air/gini/internal/xo.
+//
+// Zero valued fields take the corresponding value of DefaultOptions.
+type Options = xo.Options
+
+// DefaultOptions returns the options used by New.
+func DefaultOptions() Options {
+	return *xo.DefaultOptions()
+}
@@ -0,0 +1,375 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package proof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-air/gini/dimacs"
+	"github.com/go-air/gini/z"
+)
+
+// Format identifies a proof format.  Whether a proof is textual or binary
+// is detected automatically.
+type Format int
+
+const (
+	// Drat is the DRAT format.
+	Drat Format = iota
+	// Lrat is the LRAT format.
+	Lrat
+)
+
+func (f Format) String() string {
+	switch f {
+	case Drat:
+		return "drat"
+	case Lrat:
+		return "lrat"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ErrChecked is returned by Check if the Checker has already checked a
+// proof.
+var ErrChecked = errors.New("proof: already checked")
+
+// Type Checker checks a proof of unsatisfiability of a CNF.
+//
+// A Checker implements dimacs.CnfVis, so the CNF may be read with
+// dimacs.ReadCnf, or clauses may be added directly with Add.  Once all the
+// clauses are added, a single proof may be checked with Check.
+type Checker struct {
+	cls    [][]z.Lit // input clauses, then lemmas
+	pivots []z.Lit   // first literal of each lemma, as given
+	nIn    int
+	maxVar z.Var
+	cur    []z.Lit
+	core   []bool
+	nCore  int // number of lemmas in the core
+	done   bool
+
+	// unit propagation
+	active  []bool
+	dead    []bool // inactive for good, dropped from watches
+	units   []int
+	watches [][]int
+	vals    []int8
+	reasons []int
+	trail   []z.Lit
+	head    int
+	marks   []bool
+}
+
+// NewChecker creates a new Checker with no clauses.
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Check reads a CNF from cnf in dimacs format and checks the proof in prf
+// against it.  Check returns the Checker, from which the core may be
+// retrieved, and a non-nil error if reading or checking failed.
+func Check(cnf, prf io.Reader, f Format) (*Checker, error) {
+	c := NewChecker()
+	if e := dimacs.ReadCnf(cnf, c); e != nil {
+		return nil, e
+	}
+	return c, c.Check(prf, f)
+}
+
+// Init implements dimacs.CnfVis.
+func (c *Checker) Init(v, n int) {
+	if n > 0 && c.cls == nil {
+		c.cls = make([][]z.Lit, 0, n)
+	}
+}
+
+// Eof implements dimacs.CnfVis.
+func (c *Checker) Eof() {}
+
+// Add adds a literal to the current clause, or ends the clause if m is
+// z.LitNull, as in inter.Adder.
+func (c *Checker) Add(m z.Lit) {
+	if m != z.LitNull {
+		c.cur = append(c.cur, m)
+		return
+	}
+	c.cls = append(c.cls, c.cur)
+	c.cur = nil
+	c.nIn++
+}
+
+// Check checks the proof in r of format f.  Check returns nil if the proof
+// shows that the clauses added to c are unsatisfiable.
+func (c *Checker) Check(r io.Reader, f Format) error {
+	if c.done {
+		return ErrChecked
+	}
+	c.done = true
+	c.cls = c.cls[:c.nIn]
+	c.core = make([]bool, c.nIn)
+	c.active = make([]bool, c.nIn)
+	c.dead = make([]bool, c.nIn)
+	for i, ms := range c.cls {
+		if len(ms) == 0 {
+			// trivially unsat
+			c.core[i] = true
+			return nil
+		}
+	}
+	sr := newStepReader(r, f)
+	switch f {
+	case Drat:
+		return c.checkDrat(sr)
+	case Lrat:
+		return c.checkLrat(sr)
+	default:
+		return fmt.Errorf("proof: unknown format %s", f)
+	}
+}
+
+// Core returns the indices, in the order they were added, of the clauses
+// used in the proof, after a successful check.
+func (c *Checker) Core() []int {
+	res := make([]int, 0, c.nIn/4)
+	for i := 0; i < c.nIn; i++ {
+		if c.core[i] {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// CoreLemmas returns the number of lemmas used in the proof, after a
+// successful check.
+func (c *Checker) CoreLemmas() int {
+	return c.nCore
+}
+
+// WriteCore writes the clauses used in the proof to w in dimacs format.
+func (c *Checker) WriteCore(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	core := c.Core()
+	max := z.Var(0)
+	for _, i := range core {
+		for _, m := range c.cls[i] {
+			if m.Var() > max {
+				max = m.Var()
+			}
+		}
+	}
+	fmt.Fprintf(bw, "p cnf %d %d\n", max, len(core))
+	for _, i := range core {
+		for _, m := range c.cls[i] {
+			fmt.Fprintf(bw, "%d ", m.Dimacs())
+		}
+		fmt.Fprintf(bw, "0\n")
+	}
+	return bw.Flush()
+}
+
+// uniq removes duplicate literals from ms, reporting whether ms is a
+// tautology.
+func (c *Checker) uniq(ms []z.Lit) ([]z.Lit, bool) {
+	c.growLits(ms)
+	marks := c.marks
+	j := 0
+	taut := false
+	for _, m := range ms {
+		if marks[m] {
+			continue
+		}
+		if marks[m.Not()] {
+			taut = true
+		}
+		marks[m] = true
+		ms[j] = m
+		j++
+	}
+	ms = ms[:j]
+	for _, m := range ms {
+		marks[m] = false
+	}
+	return ms, taut
+}
+
+func (c *Checker) growLits(ms []z.Lit) {
+	for _, m := range ms {
+		if m.Var() > c.maxVar {
+			c.maxVar = m.Var()
+		}
+	}
+	n := 2 * int(c.maxVar+1)
+	if n <= len(c.vals) {
+		return
+	}
+	if n < 2*len(c.vals) {
+		n = 2 * len(c.vals)
+	}
+	vals := make([]int8, n)
+	copy(vals, c.vals)
+	c.vals = vals
+	marks := make([]bool, n)
+	copy(marks, c.marks)
+	c.marks = marks
+	watches := make([][]int, n)
+	copy(watches, c.watches)
+	c.watches = watches
+	reasons := make([]int, n/2)
+	copy(reasons, c.reasons)
+	c.reasons = reasons
+}
+
+// attach adds clause k to the unit propagation database.
+func (c *Checker) attach(k int) {
+	ms := c.cls[k]
+	c.growLits(ms)
+	c.active[k] = true
+	switch len(ms) {
+	case 0:
+	case 1:
+		c.units = append(c.units, k)
+	default:
+		c.watches[ms[0]] = append(c.watches[ms[0]], k)
+		c.watches[ms[1]] = append(c.watches[ms[1]], k)
+	}
+}
+
+func (c *Checker) assign(m z.Lit, reason int) {
+	c.vals[m] = 1
+	c.vals[m.Not()] = -1
+	c.reasons[m.Var()] = reason
+	c.trail = append(c.trail, m)
+}
+
+// rup assigns the negation of ms and propagates, returning the conflicting
+// clause, -1 if there is no conflict, or -2 if ms is a tautology.  The
+// caller must reset the assignment.
+func (c *Checker) rup(ms []z.Lit) int {
+	c.growLits(ms)
+	for _, m := range ms {
+		switch c.vals[m] {
+		case 1:
+			return -2
+		case 0:
+			c.assign(m.Not(), -1)
+		}
+	}
+	for _, k := range c.units {
+		if !c.active[k] {
+			continue
+		}
+		m := c.cls[k][0]
+		switch c.vals[m] {
+		case -1:
+			return k
+		case 0:
+			c.assign(m, k)
+		}
+	}
+	return c.propagate()
+}
+
+func (c *Checker) propagate() int {
+	vals := c.vals
+	for c.head < len(c.trail) {
+		f := c.trail[c.head].Not()
+		c.head++
+		ws := c.watches[f]
+		j := 0
+		for i := 0; i < len(ws); i++ {
+			k := ws[i]
+			if !c.active[k] {
+				if !c.dead[k] {
+					ws[j] = k
+					j++
+				}
+				continue
+			}
+			ms := c.cls[k]
+			if ms[0] == f {
+				ms[0], ms[1] = ms[1], f
+			}
+			if vals[ms[0]] == 1 {
+				ws[j] = k
+				j++
+				continue
+			}
+			moved := false
+			for q := 2; q < len(ms); q++ {
+				n := ms[q]
+				if vals[n] != -1 {
+					ms[1], ms[q] = n, f
+					c.watches[n] = append(c.watches[n], k)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			ws[j] = k
+			j++
+			if vals[ms[0]] == -1 {
+				j += copy(ws[j:], ws[i+1:])
+				c.watches[f] = ws[:j]
+				return k
+			}
+			c.assign(ms[0], k)
+		}
+		c.watches[f] = ws[:j]
+	}
+	return -1
+}
+
+// analyze marks the clauses involved in the conflict x as core.
+func (c *Checker) analyze(x int) {
+	marks := c.marks
+	c.markCore(x)
+	for _, m := range c.cls[x] {
+		marks[m.Var()] = true
+	}
+	for i := len(c.trail) - 1; i >= 0; i-- {
+		v := c.trail[i].Var()
+		if !marks[v] {
+			continue
+		}
+		marks[v] = false
+		r := c.reasons[v]
+		if r < 0 {
+			continue
+		}
+		c.markCore(r)
+		for _, m := range c.cls[r] {
+			marks[m.Var()] = true
+		}
+	}
+	for _, m := range c.cls[x] {
+		marks[m.Var()] = false
+	}
+}
+
+func (c *Checker) markCore(k int) {
+	if c.core[k] {
+		return
+	}
+	c.core[k] = true
+	if k >= c.nIn {
+		c.nCore++
+	}
+}
+
+// reset undoes all assignments.
+func (c *Checker) reset() {
+	for _, m := range c.trail {
+		c.vals[m] = 0
+		c.vals[m.Not()] = 0
+	}
+	c.trail = c.trail[:0]
+	c.head = 0
+}
@@ -0,0 +1,16 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+// Package proof checks proofs of unsatisfiability in the DRAT and LRAT
+// formats, textual or binary, such as those written by gini.
+//
+// A Checker is given a CNF, for example by dimacs.ReadCnf, and then checks a
+// proof against it.  DRAT proofs are checked backwards, starting from the
+// empty clause, so that only the lemmas which are needed are checked.  LRAT
+// proofs are checked forwards using their hints.  In both cases, a
+// successful check identifies an unsatisfiable core of the CNF, which may be
+// retrieved with Core or WriteCore.
+//
+// As is common for DRAT checkers, deletions of unit clauses and of clauses
+// which are not present are ignored.
+package proof
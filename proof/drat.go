@@ -0,0 +1,194 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package proof
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-air/gini/z"
+)
+
+// Type dratStep records a step of a DRAT proof for backward checking.
+type dratStep struct {
+	k   int // clause index
+	del bool
+}
+
+// newLemma adds the lemma ms to c.cls, returning its index.
+func (c *Checker) newLemma(ms []z.Lit) int {
+	k := len(c.cls)
+	pivot := z.LitNull
+	if len(ms) > 0 {
+		pivot = ms[0]
+	}
+	ms, _ = c.uniq(append([]z.Lit(nil), ms...))
+	c.cls = append(c.cls, ms)
+	c.pivots = append(c.pivots, pivot)
+	c.active = append(c.active, false)
+	c.dead = append(c.dead, false)
+	c.core = append(c.core, false)
+	return k
+}
+
+// hash returns a hash of the clause ms independent of literal order.
+func hash(ms []z.Lit) uint64 {
+	var h uint64
+	for _, m := range ms {
+		u := uint64(m) * 0x9e3779b97f4a7c15
+		h += u ^ (u >> 29)
+	}
+	return h + uint64(len(ms))
+}
+
+// sameClause returns whether ms and ns have the same literals, ms having
+// no duplicates.
+func (c *Checker) sameClause(ms, ns []z.Lit) bool {
+	c.growLits(ns)
+	marks := c.marks
+	for _, m := range ms {
+		marks[m] = true
+	}
+	n := 0
+	ok := true
+	for _, m := range ns {
+		if !marks[m] {
+			ok = false
+			break
+		}
+		marks[m] = false
+		n++
+	}
+	for _, m := range ms {
+		marks[m] = false
+	}
+	return ok && n == len(ms)
+}
+
+func (c *Checker) checkDrat(sr *stepReader) error {
+	index := make(map[uint64][]int, len(c.cls))
+	for k, ms := range c.cls {
+		c.cls[k], _ = c.uniq(ms)
+		c.attach(k)
+		h := hash(c.cls[k])
+		index[h] = append(index[h], k)
+	}
+
+	// forward pass: just apply the steps until the empty clause.
+	steps := make([]dratStep, 0, 1024)
+	st := &step{}
+	empty := -1
+	for empty == -1 {
+		e := sr.next(st)
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return e
+		}
+		if st.del {
+			ms, _ := c.uniq(st.ms)
+			h := hash(ms)
+			ks := index[h]
+			for i := len(ks) - 1; i >= 0; i-- {
+				k := ks[i]
+				if !c.sameClause(c.cls[k], ms) {
+					continue
+				}
+				if len(ms) > 1 {
+					c.active[k] = false
+					steps = append(steps, dratStep{k: k, del: true})
+					index[h] = append(ks[:i], ks[i+1:]...)
+				}
+				break
+			}
+			continue
+		}
+		k := c.newLemma(st.ms)
+		steps = append(steps, dratStep{k: k})
+		if len(c.cls[k]) == 0 {
+			empty = k
+			break
+		}
+		c.attach(k)
+		h := hash(c.cls[k])
+		index[h] = append(index[h], k)
+	}
+	if empty == -1 {
+		// the proof may stop once unit propagation gives a conflict.
+		empty = c.newLemma(nil)
+		steps = append(steps, dratStep{k: empty})
+	}
+
+	// backward pass: check the lemmas in the core.
+	c.markCore(empty)
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if s.del {
+			c.active[s.k] = true
+			continue
+		}
+		c.active[s.k] = false
+		c.dead[s.k] = true
+		if !c.core[s.k] {
+			continue
+		}
+		if e := c.checkRat(s.k); e != nil {
+			return fmt.Errorf("lemma %d: %s", s.k-c.nIn+1, e)
+		}
+	}
+	return nil
+}
+
+// checkRat checks that lemma k is RUP or RAT on its pivot with respect to
+// the active clauses, marking the clauses used as core.
+func (c *Checker) checkRat(k int) error {
+	ms := c.cls[k]
+	x := c.rup(ms)
+	if x >= 0 {
+		c.analyze(x)
+	}
+	c.reset()
+	if x != -1 {
+		return nil
+	}
+	p := c.pivots[k-c.nIn]
+	if p == z.LitNull {
+		return fmt.Errorf("empty clause not implied by unit propagation")
+	}
+	np := p.Not()
+	var rs []z.Lit
+	for j, ns := range c.cls {
+		if !c.active[j] {
+			continue
+		}
+		found := false
+		for _, n := range ns {
+			if n == np {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		rs = append(rs[:0], ms...)
+		for _, n := range ns {
+			if n != np {
+				rs = append(rs, n)
+			}
+		}
+		rs, _ = c.uniq(rs)
+		x := c.rup(rs)
+		if x >= 0 {
+			c.analyze(x)
+		}
+		c.reset()
+		if x == -1 {
+			return fmt.Errorf("%v neither RUP nor RAT on %s", ms, p)
+		}
+		c.markCore(j)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package proof
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-air/gini/z"
+)
+
+// Type lratLemma records the id and hints of a lemma of an LRAT proof for
+// core trimming.
+type lratLemma struct {
+	id    int64
+	hints []int64
+}
+
+func (c *Checker) checkLrat(sr *stepReader) error {
+	// clause ids are 1 + index for input clauses.
+	ids := make(map[int64]int, len(c.cls))
+	for k, ms := range c.cls {
+		c.cls[k], _ = c.uniq(ms)
+		c.growLits(c.cls[k])
+		ids[int64(k+1)] = k
+	}
+	lemmas := make([]lratLemma, 0, 1024)
+	lemmaIds := make(map[int64]int, 1024)
+	st := &step{}
+	for {
+		e := sr.next(st)
+		if e == io.EOF {
+			return fmt.Errorf("no empty clause")
+		}
+		if e != nil {
+			return e
+		}
+		if st.del {
+			for _, h := range st.hints {
+				delete(ids, h)
+			}
+			continue
+		}
+		if _, ok := ids[st.id]; ok || st.id <= int64(c.nIn) {
+			return fmt.Errorf("lemma %d: bad id", st.id)
+		}
+		if _, ok := lemmaIds[st.id]; ok {
+			return fmt.Errorf("lemma %d: bad id", st.id)
+		}
+		k := c.newLemma(st.ms)
+		if e := c.checkHints(ids, k, st.hints); e != nil {
+			return fmt.Errorf("lemma %d: %s", st.id, e)
+		}
+		ids[st.id] = k
+		lemmaIds[st.id] = len(lemmas)
+		lemmas = append(lemmas, lratLemma{
+			id:    st.id,
+			hints: append([]int64(nil), st.hints...)})
+		if len(c.cls[k]) == 0 {
+			break
+		}
+	}
+
+	// trim: mark the clauses used by the empty clause.
+	used := make(map[int64]bool, len(lemmas))
+	used[lemmas[len(lemmas)-1].id] = true
+	for i := len(lemmas) - 1; i >= 0; i-- {
+		l := &lemmas[i]
+		if !used[l.id] {
+			continue
+		}
+		c.nCore++
+		for _, h := range l.hints {
+			if h < 0 {
+				h = -h
+			}
+			if h <= int64(c.nIn) {
+				c.core[h-1] = true
+				continue
+			}
+			used[h] = true
+		}
+	}
+	return nil
+}
+
+// checkHints checks that lemma k follows from the clauses in ids by unit
+// propagation with the given hints, or by RAT on its pivot with hints
+// for each resolution candidate, as in LRAT.
+func (c *Checker) checkHints(ids map[int64]int, k int, hints []int64) error {
+	defer c.reset()
+	ms := c.cls[k]
+	for _, m := range ms {
+		switch c.vals[m] {
+		case 1:
+			return nil
+		case 0:
+			c.assign(m.Not(), -1)
+		}
+	}
+	i := 0
+	for ; i < len(hints) && hints[i] > 0; i++ {
+		x, e := c.hintUnit(ids, hints[i])
+		if e != nil {
+			return e
+		}
+		if x {
+			return nil
+		}
+	}
+
+	// rat: each clause containing the negated pivot must be listed,
+	// followed by hints for its resolvent.
+	p := c.pivots[k-c.nIn]
+	if p == z.LitNull {
+		return fmt.Errorf("hints do not give a conflict")
+	}
+	np := p.Not()
+	top := len(c.trail)
+	cands := make(map[int64]bool)
+	for i < len(hints) {
+		h := -hints[i]
+		i++
+		j, ok := ids[h]
+		if !ok {
+			return fmt.Errorf("unknown rat candidate %d", h)
+		}
+		cands[h] = true
+		x := false
+		for _, n := range c.cls[j] {
+			if n == np {
+				continue
+			}
+			switch c.vals[n] {
+			case 1:
+				x = true
+			case 0:
+				c.assign(n.Not(), -1)
+			}
+		}
+		for ; i < len(hints) && hints[i] > 0; i++ {
+			if x {
+				continue
+			}
+			var e error
+			if x, e = c.hintUnit(ids, hints[i]); e != nil {
+				return e
+			}
+		}
+		if !x {
+			return fmt.Errorf("hints for rat candidate %d do not give a conflict", h)
+		}
+		c.undo(top)
+	}
+	for h, j := range ids {
+		if cands[h] {
+			continue
+		}
+		for _, n := range c.cls[j] {
+			if n == np {
+				return fmt.Errorf("missing rat candidate %d", h)
+			}
+		}
+	}
+	return nil
+}
+
+// hintUnit applies the hint clause with id h under the current
+// assignment, returning whether it is false.
+func (c *Checker) hintUnit(ids map[int64]int, h int64) (bool, error) {
+	j, ok := ids[h]
+	if !ok {
+		return false, fmt.Errorf("unknown hint %d", h)
+	}
+	unit := z.LitNull
+	for _, n := range c.cls[j] {
+		switch c.vals[n] {
+		case 1:
+			return false, fmt.Errorf("hint %d is satisfied", h)
+		case 0:
+			if unit != z.LitNull {
+				return false, fmt.Errorf("hint %d is not unit", h)
+			}
+			unit = n
+		}
+	}
+	if unit == z.LitNull {
+		return true, nil
+	}
+	c.assign(unit, j)
+	return false, nil
+}
+
+// undo undoes the assignments after the first n on the trail.
+func (c *Checker) undo(n int) {
+	for _, m := range c.trail[n:] {
+		c.vals[m] = 0
+		c.vals[m.Not()] = 0
+	}
+	c.trail = c.trail[:n]
+	if c.head > n {
+		c.head = n
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package proof_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-air/gini"
+	"github.com/go-air/gini/dimacs"
+	"github.com/go-air/gini/gen"
+	"github.com/go-air/gini/proof"
+	"github.com/go-air/gini/z"
+)
+
+func cnfAndProof(t *testing.T, f gini.ProofFormat) (cnf, prf *bytes.Buffer) {
+	g := gini.New()
+	prf = bytes.NewBuffer(nil)
+	g.SetProof(prf, f)
+	gen.Php(g, 7, 6)
+	cnf = bytes.NewBuffer(nil)
+	if e := g.Write(cnf); e != nil {
+		t.Fatal(e)
+	}
+	if g.Solve() != -1 {
+		t.Fatal("php not unsat")
+	}
+	if e := g.ProofError(); e != nil {
+		t.Fatal(e)
+	}
+	return cnf, prf
+}
+
+func TestCheck(t *testing.T) {
+	for _, f := range []gini.ProofFormat{gini.DratText, gini.DratBinary, gini.LratText, gini.LratBinary} {
+		cnf, prf := cnfAndProof(t, f)
+		pf := proof.Drat
+		if f == gini.LratText || f == gini.LratBinary {
+			pf = proof.Lrat
+		}
+		c, e := proof.Check(bytes.NewReader(cnf.Bytes()), prf, pf)
+		if e != nil {
+			t.Fatalf("format %d: %s", f, e)
+		}
+		core := c.Core()
+		if len(core) == 0 || c.CoreLemmas() == 0 {
+			t.Fatalf("format %d: empty core", f)
+		}
+		// the core must itself be unsat.
+		buf := bytes.NewBuffer(nil)
+		if e := c.WriteCore(buf); e != nil {
+			t.Fatal(e)
+		}
+		g := gini.New()
+		if e := dimacs.ReadCnf(buf, &adder{g}); e != nil {
+			t.Fatal(e)
+		}
+		if g.Solve() != -1 {
+			t.Errorf("format %d: core not unsat", f)
+		}
+	}
+}
+
+type adder struct {
+	g *gini.Gini
+}
+
+func (a *adder) Init(v, c int) {}
+func (a *adder) Eof()          {}
+func (a *adder) Add(m z.Lit)   { a.g.Add(m) }
+
+func TestCheckRat(t *testing.T) {
+	// lemma 3 is RAT but not RUP on 3, the remaining lemmas are RUP.
+	cnf := `p cnf 2 4
+1 2 0
+-1 2 0
+1 -2 0
+-1 -2 0
+`
+	prf := `3 0
+-3 1 0
+0
+`
+	c, e := proof.Check(strings.NewReader(cnf), strings.NewReader(prf), proof.Drat)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(c.Core()) != 4 {
+		t.Errorf("core %v", c.Core())
+	}
+	lrat := `5 3 0 0
+6 -3 1 0 1 3 0
+7 0 5 6 2 4 0
+`
+	_, e = proof.Check(strings.NewReader(cnf), strings.NewReader(lrat), proof.Lrat)
+	if e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestCheckBad(t *testing.T) {
+	cnf := `p cnf 2 3
+1 2 0
+-1 2 0
+1 -2 0
+`
+	for _, prf := range []string{"2 0\n0\n", "-1 0\n0\n", ""} {
+		_, e := proof.Check(strings.NewReader(cnf), strings.NewReader(prf), proof.Drat)
+		if e == nil {
+			t.Errorf("proof %q of sat cnf checked", prf)
+		}
+	}
+	for _, prf := range []string{"4 2 1 2 0\n5 0 4 3 0\n", "4 2 1 0\n5 0 4 3 0\n"} {
+		_, e := proof.Check(strings.NewReader(cnf), strings.NewReader(prf), proof.Lrat)
+		if e == nil {
+			t.Errorf("proof %q of sat cnf checked", prf)
+		}
+	}
+}
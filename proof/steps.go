@@ -0,0 +1,292 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package proof
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-air/gini/z"
+)
+
+// Type step is a single step of a proof.
+type step struct {
+	del   bool
+	id    int64   // lrat only
+	ms    []z.Lit // literals of added clause
+	hints []int64 // lrat hints or deleted ids
+}
+
+// Type stepReader reads proof steps in any supported format.
+type stepReader struct {
+	r      *bufio.Reader
+	lrat   bool
+	binary bool
+	n      int // number of steps read
+}
+
+func newStepReader(r io.Reader, f Format) *stepReader {
+	br := bufio.NewReader(r)
+	return &stepReader{
+		r:      br,
+		lrat:   f == Lrat,
+		binary: isBinary(br)}
+}
+
+// isBinary guesses whether the proof in r is binary from its first bytes.
+func isBinary(r *bufio.Reader) bool {
+	bs, _ := r.Peek(16)
+	if len(bs) > 0 && bs[0] == 'c' {
+		return false
+	}
+	for _, b := range bs {
+		switch {
+		case b >= '0' && b <= '9':
+		case b == ' ', b == '\t', b == '\n', b == '\r', b == '-', b == 'd':
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// next reads the next step into st, returning io.EOF if there are no more
+// steps.
+func (s *stepReader) next(st *step) error {
+	st.del = false
+	st.id = 0
+	st.ms = st.ms[:0]
+	st.hints = st.hints[:0]
+	var e error
+	if s.binary {
+		e = s.nextBinary(st)
+	} else {
+		e = s.nextText(st)
+	}
+	if e == nil {
+		s.n++
+	} else if e != io.EOF {
+		e = fmt.Errorf("proof step %d: %s", s.n+1, e)
+	}
+	return e
+}
+
+func (s *stepReader) nextBinary(st *step) error {
+	op, e := s.r.ReadByte()
+	if e != nil {
+		return e
+	}
+	switch op {
+	case 'a':
+	case 'd':
+		st.del = true
+	default:
+		return fmt.Errorf("bad binary proof operation %q", op)
+	}
+	if s.lrat {
+		if !st.del {
+			if st.id, e = s.readSigned(); e != nil {
+				return e
+			}
+			if e = s.readBinaryLits(st); e != nil {
+				return e
+			}
+		}
+		for {
+			h, e := s.readSigned()
+			if e != nil {
+				return e
+			}
+			if h == 0 {
+				return nil
+			}
+			st.hints = append(st.hints, h)
+		}
+	}
+	return s.readBinaryLits(st)
+}
+
+func (s *stepReader) readBinaryLits(st *step) error {
+	for {
+		u, e := s.readVarint()
+		if e != nil {
+			return e
+		}
+		if u == 0 {
+			return nil
+		}
+		if u < 2 || u > math.MaxUint32 {
+			return fmt.Errorf("bad binary literal %d", u)
+		}
+		st.ms = append(st.ms, z.Lit(u))
+	}
+}
+
+func (s *stepReader) readSigned() (int64, error) {
+	u, e := s.readVarint()
+	if e != nil {
+		return 0, e
+	}
+	if u&1 != 0 {
+		return -int64(u >> 1), nil
+	}
+	return int64(u >> 1), nil
+}
+
+func (s *stepReader) readVarint() (uint64, error) {
+	var u uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, e := s.r.ReadByte()
+		if e == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if e != nil {
+			return 0, e
+		}
+		u |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return u, nil
+		}
+	}
+	return 0, fmt.Errorf("varint overflow")
+}
+
+func (s *stepReader) nextText(st *step) error {
+	if s.lrat {
+		id, e := s.readInt(true)
+		if e != nil {
+			return e
+		}
+		st.id = id
+	}
+	del, e := s.readDel(!s.lrat)
+	if e == io.EOF && s.lrat {
+		return io.ErrUnexpectedEOF
+	}
+	if e != nil {
+		return e
+	}
+	st.del = del
+	if s.lrat && del {
+		return s.readIds(st)
+	}
+	if e := s.readLits(st); e != nil {
+		return e
+	}
+	if s.lrat {
+		return s.readIds(st)
+	}
+	return nil
+}
+
+func (s *stepReader) readLits(st *step) error {
+	for {
+		v, e := s.readInt(false)
+		if e != nil {
+			return e
+		}
+		if v == 0 {
+			return nil
+		}
+		st.ms = append(st.ms, z.Dimacs2Lit(int(v)))
+	}
+}
+
+func (s *stepReader) readIds(st *step) error {
+	for {
+		v, e := s.readInt(false)
+		if e != nil {
+			return e
+		}
+		if v == 0 {
+			return nil
+		}
+		st.hints = append(st.hints, v)
+	}
+}
+
+// readDel skips white space, and comments if stepStart, and consumes
+// a 'd' if it is next.
+func (s *stepReader) readDel(stepStart bool) (bool, error) {
+	b, e := s.skip(stepStart)
+	if e != nil {
+		return false, e
+	}
+	if b != 'd' {
+		return false, s.r.UnreadByte()
+	}
+	return true, nil
+}
+
+// skip skips white space and, if lineStart, comment lines, returning
+// the next byte.
+func (s *stepReader) skip(lineStart bool) (byte, error) {
+	for {
+		b, e := s.r.ReadByte()
+		if e != nil {
+			return 0, e
+		}
+		switch b {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			lineStart = true
+			continue
+		case 'c':
+			if !lineStart {
+				return b, nil
+			}
+			if _, e := s.r.ReadString('\n'); e != nil {
+				return 0, e
+			}
+			continue
+		}
+		return b, nil
+	}
+}
+
+// readInt reads an integer.  If stepStart, comment lines are skipped
+// first, and io.EOF is returned if there is no integer.
+func (s *stepReader) readInt(stepStart bool) (int64, error) {
+	b, e := s.skip(stepStart)
+	if e == io.EOF && !stepStart {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if e != nil {
+		return 0, e
+	}
+	neg := false
+	if b == '-' {
+		neg = true
+		if b, e = s.r.ReadByte(); e != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+	if b < '0' || b > '9' {
+		return 0, fmt.Errorf("bad character for int: %q", b)
+	}
+	v := int64(b - '0')
+	for {
+		b, e = s.r.ReadByte()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return 0, e
+		}
+		if b < '0' || b > '9' {
+			if e := s.r.UnreadByte(); e != nil {
+				return 0, e
+			}
+			break
+		}
+		v = v*10 + int64(b-'0')
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
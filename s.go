@@ -3,10 +3,24 @@
 
 package gini
 
-import "github.com/go-air/gini/inter"
+import (
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/internal/xo"
+)
 
 // NewS creates a new solver, which is the Gini
 // implementation of inter.S.
 func NewS() inter.S {
 	return New()
 }
+
+// NewPortfolio creates a solver which runs n differently configured
+// solvers concurrently on the same problem, sharing short learnt clauses
+// between them.  The first solver to find a result gives the result.
+//
+// Each call to Solve or GoSolve uses n goroutines until it returns a
+// result.  The returned solver should be stopped with Stop when it is no
+// longer used.
+func NewPortfolio(n int) inter.Sc {
+	return xo.NewPortfolio(n)
+}
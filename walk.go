@@ -0,0 +1,56 @@
+// Copyright 2016 The Gini Authors. All rights reserved.  Use of this source
+// code is governed by a license that can be found in the License file.
+
+package gini
+
+import (
+	"time"
+
+	"github.com/go-air/gini/internal/xo"
+	"github.com/go-air/gini/z"
+)
+
+// Walk is a stochastic local search, in the style of ProbSAT, over the
+// clauses of a Gini.  Walk implements inter.Solvable and inter.Model.
+// As local search cannot show unsatisfiability, Solve and Try return 1
+// if a model is found and 0 otherwise, never -1.
+//
+// Local search often finds models of satisfiable random or loosely
+// constrained problems faster than Solve.  It is also used by Solve to
+// reset the saved phases, see RephaseWalk.
+type Walk struct {
+	w *xo.Walk
+}
+
+// NewWalk creates a local search over the clauses currently added to g,
+// randomized by seed.  Variables whose values are fixed by g without
+// assumptions are fixed in the search.  Cardinality, pseudo-Boolean and
+// xor constraints are checked on the models found, but do not guide the
+// search.  Changes to g after NewWalk returns do not affect the Walk.
+func NewWalk(g *Gini, seed int64) *Walk {
+	return &Walk{w: xo.NewWalk(g.xo, seed)}
+}
+
+// Solve makes at most Options.WalkFlips flips, returning 1 if a model is
+// found and 0 otherwise.
+func (w *Walk) Solve() int {
+	return w.w.Solve()
+}
+
+// Try searches for a model for at most dur, returning 1 if one is found
+// and 0 otherwise.
+func (w *Walk) Try(dur time.Duration) int {
+	return w.w.Try(dur)
+}
+
+// Value returns the value of m in the model found by the last call to
+// Solve or Try returning 1.
+func (w *Walk) Value(m z.Lit) bool {
+	return w.w.Value(m)
+}
+
+// Unsat returns the least number of false clauses under an assignment
+// found by the search so far.
+func (w *Walk) Unsat() int {
+	return w.w.Unsat()
+}